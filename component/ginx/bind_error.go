@@ -0,0 +1,170 @@
+package ginx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// FieldError 请求参数的字段错误, field 和 message 与 grpcx.FieldErrorDetail 一致
+type FieldError struct {
+	Field     string `json:"field"`               // 字段路径, 如 user.emails[0], 请求体格式错误时为空
+	Rule      string `json:"rule"`                // 校验规则, 如 required, 类型错误为 type, 请求体格式错误为 json
+	Message   string `json:"message"`             // 英文描述
+	Localized string `json:"localized,omitempty"` // 按 Accept-Language 翻译的描述
+}
+
+// BindError 请求参数绑定错误, 请求体格式错误时状态码为 400, 类型错误和校验失败为 422
+type BindError struct {
+	StatusCode  int
+	FieldErrors []FieldError
+}
+
+func (e *BindError) Error() string {
+	messages := make([]string, 0, len(e.FieldErrors))
+	for _, fe := range e.FieldErrors {
+		if fe.Field == "" {
+			messages = append(messages, fe.Message)
+		} else {
+			messages = append(messages, fe.Field+": "+fe.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+var (
+	uni     *ut.UniversalTranslator
+	uniOnce sync.Once
+
+	// bindMessages 非校验错误的描述
+	bindMessages = map[string]map[string]string{
+		"en": {"json": "request body is not valid JSON", "type": "must be a valid %s"},
+		"zh": {"json": "请求体不是有效的 JSON", "type": "必须是有效的%s"},
+	}
+)
+
+// setupValidator 字段名使用 json, form 或 uri 标签, 并注册中英文翻译
+func setupValidator() {
+	uniOnce.Do(func() {
+		uni = ut.New(en.New(), en.New(), zh.New())
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return f.Name
+		})
+		enTrans, _ := uni.GetTranslator("en")
+		zhTrans, _ := uni.GetTranslator("zh")
+		_ = enTranslations.RegisterDefaultTranslations(v, enTrans)
+		_ = zhTranslations.RegisterDefaultTranslations(v, zhTrans)
+	})
+}
+
+// translator 按 Accept-Language 选择翻译, 不支持时使用英文
+func translator(ctx *gin.Context) (ut.Translator, string) {
+	var locales []string
+	for _, part := range strings.Split(ctx.GetHeader("Accept-Language"), ",") {
+		locale, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		locale, _, _ = strings.Cut(strings.ReplaceAll(locale, "-", "_"), "_")
+		if locale != "" {
+			locales = append(locales, strings.ToLower(locale))
+		}
+	}
+	trans, found := uni.FindTranslator(locales...)
+	if !found {
+		return trans, "en"
+	}
+	return trans, trans.Locale()
+}
+
+// isValidationErr 绑定时的校验错误, 绑定部分参数时会校验其他来源的字段, 统一在绑定完成后校验
+func isValidationErr(err error) bool {
+	var ve validator.ValidationErrors
+	return errors.As(err, &ve)
+}
+
+// newBindError 把绑定和校验的错误转换为 BindError, 不是参数错误时返回 nil
+func newBindError(ctx *gin.Context, err error) *BindError {
+	trans, locale := translator(ctx)
+	messages := bindMessages[locale]
+	if messages == nil {
+		messages = bindMessages["en"]
+	}
+
+	var (
+		ve        validator.ValidationErrors
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		numErr    *strconv.NumError
+	)
+	switch {
+	case errors.As(err, &ve):
+		enTrans, _ := uni.GetTranslator("en")
+		fieldErrors := make([]FieldError, 0, len(ve))
+		for _, fe := range ve {
+			fieldErrors = append(fieldErrors, FieldError{
+				Field:     fieldPath(fe.Namespace()),
+				Rule:      fe.Tag(),
+				Message:   fe.Translate(enTrans),
+				Localized: fe.Translate(trans),
+			})
+		}
+		return &BindError{StatusCode: http.StatusUnprocessableEntity, FieldErrors: fieldErrors}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &BindError{StatusCode: http.StatusBadRequest, FieldErrors: []FieldError{{
+			Rule:      "json",
+			Message:   fmt.Sprintf("%s: %s", bindMessages["en"]["json"], err),
+			Localized: messages["json"],
+		}}}
+	case errors.As(err, &typeErr):
+		return &BindError{StatusCode: http.StatusUnprocessableEntity, FieldErrors: []FieldError{{
+			Field:     typeErr.Field,
+			Rule:      "type",
+			Message:   fmt.Sprintf(bindMessages["en"]["type"], typeErr.Type),
+			Localized: fmt.Sprintf(messages["type"], typeErr.Type),
+		}}}
+	case errors.As(err, &numErr):
+		// 表单绑定的错误不包含字段名
+		return &BindError{StatusCode: http.StatusUnprocessableEntity, FieldErrors: []FieldError{{
+			Rule:      "type",
+			Message:   fmt.Sprintf("%q is not a valid number", numErr.Num),
+			Localized: fmt.Sprintf(messages["type"], "number"),
+		}}}
+	default:
+		return nil
+	}
+}
+
+// fieldPath 去掉校验错误命名空间中的结构体名
+func fieldPath(namespace string) string {
+	_, path, ok := strings.Cut(namespace, ".")
+	if !ok {
+		return namespace
+	}
+	return path
+}
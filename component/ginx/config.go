@@ -0,0 +1,10 @@
+package ginx
+
+type config struct {
+	Addr     string        `mapstructure:"addr" validate:"required"`                 // 服务地址
+	CORS     bool          `mapstructure:"cors"`                                     // 是否允许跨域
+	LTS      bool          `mapstructure:"lts"`                                      // 是否开启 tls
+	CertFile string        `mapstructure:"certFile" validate:"required_if=LTS true"` // 证书文件
+	KeyFile  string        `mapstructure:"keyFile" validate:"required_if=LTS true"`  // 私钥文件
+	OpenAPI  openAPIConfig `mapstructure:"openapi"`
+}
@@ -3,15 +3,19 @@ package ginx
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/goslacker/slacker/component/ginx/middleware"
 	"github.com/goslacker/slacker/core/app"
 	"github.com/goslacker/slacker/core/slicex"
-	"github.com/spf13/viper"
 )
 
 func NewGinx() *Ginx {
@@ -22,48 +26,83 @@ type Ginx struct {
 	app.Component
 	router gin.IRouter
 	svr    *http.Server
+	ready  atomic.Bool
+	docs   *openAPI
+	conf   config
 }
 
+var _ app.Serviceable = (*Ginx)(nil)
+
+// Start 兼容旧的 Serviceable 接口, 调用 Serve 并记录错误
 func (g *Ginx) Start() {
-	c := viper.Sub("ginx")
+	if err := g.Serve(); err != nil {
+		slog.Error("ginx serve failed", "error", err)
+	}
+}
 
+// Serve 启动服务并阻塞, 端口监听失败时返回错误
+func (g *Ginx) Serve() (err error) {
 	g.svr = &http.Server{
+		Addr:    g.conf.Addr,
 		Handler: g.router.(http.Handler),
 	}
-	g.svr.Addr = c.GetString("addr")
 
-	var err error
-	if c.GetBool("lts") {
-		err = g.svr.ListenAndServeTLS(c.GetString("certFile"), c.GetString("keyFile"))
+	lis, err := net.Listen("tcp", g.svr.Addr)
+	if err != nil {
+		return fmt.Errorf("server listen failed: %w", err)
+	}
+	g.ready.Store(true)
+	defer g.ready.Store(false)
+	if g.conf.LTS {
+		err = g.svr.ServeTLS(lis, g.conf.CertFile, g.conf.KeyFile)
 	} else {
-		err = g.svr.ListenAndServe()
+		err = g.svr.Serve(lis)
 	}
-	if err != nil {
-		slog.Info("server shutdown", "error", err)
+	if errors.Is(err, http.ErrServerClosed) {
+		slog.Info("server shutdown")
+		return nil
 	}
+	return
+}
+
+// Ready http端口监听成功后就绪
+func (g *Ginx) Ready() error {
+	if !g.ready.Load() {
+		return errors.New("http server is not serving")
+	}
+	return nil
 }
 
 func (g *Ginx) Stop() {
+	g.ready.Store(false)
+	if g.svr == nil {
+		return
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	_ = g.svr.Shutdown(ctx)
 }
 
+func (g *Ginx) Name() string {
+	return "ginx"
+}
+
 func (g *Ginx) Init() (err error) {
-	c, err := app.Resolve[*viper.Viper]()
+	g.conf, err = app.Config[config]("ginx")
 	if err != nil {
 		return
 	}
-	c = c.Sub("ginx")
-	if c == nil {
-		return errors.New("ginx init failed: no config found")
-	}
 	g.router = gin.Default()
-	if c.GetBool("cors") {
+	g.router.Use(middleware.Scope)
+	if g.conf.CORS {
 		g.router.Use(middleware.CORS)
 	}
 	g.router.Use(middleware.Options)
 
+	if g.conf.OpenAPI.Enabled {
+		g.serveOpenAPI(g.conf.OpenAPI)
+	}
+
 	err = app.Bind[Router](g)
 	if err != nil {
 		return
@@ -79,51 +118,66 @@ func (g *Ginx) Use(a ...any) Router {
 
 func (g *Ginx) Handle(s string, s2 string, a ...any) Router {
 	g.router.Handle(s, s2, convertHandlers(a)...)
+	g.docs.add(s, g.fullPath(s2), a)
 	return g
 }
 
 func (g *Ginx) Any(s string, a ...any) Router {
 	g.router.Any(s, convertHandlers(a)...)
+	// 文档中只列出常用的方法
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		g.docs.add(method, g.fullPath(s), a)
+	}
 	return g
 }
 
 func (g *Ginx) GET(s string, a ...any) Router {
 	g.router.GET(s, convertHandlers(a)...)
+	g.docs.add(http.MethodGet, g.fullPath(s), a)
 	return g
 }
 
 func (g *Ginx) POST(s string, a ...any) Router {
 	g.router.POST(s, convertHandlers(a)...)
+	g.docs.add(http.MethodPost, g.fullPath(s), a)
 	return g
 }
 
 func (g *Ginx) DELETE(s string, a ...any) Router {
 	g.router.DELETE(s, convertHandlers(a)...)
+	g.docs.add(http.MethodDelete, g.fullPath(s), a)
 	return g
 }
 
 func (g *Ginx) PATCH(s string, a ...any) Router {
 	g.router.PATCH(s, convertHandlers(a)...)
+	g.docs.add(http.MethodPatch, g.fullPath(s), a)
 	return g
 }
 
 func (g *Ginx) PUT(s string, a ...any) Router {
 	g.router.PUT(s, convertHandlers(a)...)
+	g.docs.add(http.MethodPut, g.fullPath(s), a)
 	return g
 }
 
 func (g *Ginx) OPTIONS(s string, a ...any) Router {
 	g.router.OPTIONS(s, convertHandlers(a)...)
+	g.docs.add(http.MethodOptions, g.fullPath(s), a)
 	return g
 }
 
 func (g *Ginx) HEAD(s string, a ...any) Router {
 	g.router.HEAD(s, convertHandlers(a)...)
+	g.docs.add(http.MethodHead, g.fullPath(s), a)
 	return g
 }
 
 func (g *Ginx) Match(strings []string, s string, a ...any) Router {
 	g.router.Match(strings, s, convertHandlers(a)...)
+	for _, method := range strings {
+		g.docs.add(method, g.fullPath(s), a)
+	}
 	return g
 }
 
@@ -132,7 +186,21 @@ func (g *Ginx) Group(s string, a ...any) Router {
 		router: g.router.Group(s, slicex.MustMap(a, func(item any) gin.HandlerFunc {
 			return WrapMiddleware(item)
 		})...),
+		docs: g.docs,
+	}
+}
+
+// fullPath 加上分组的前缀
+func (g *Ginx) fullPath(relativePath string) string {
+	group, ok := g.router.(interface{ BasePath() string })
+	if !ok || relativePath == "" {
+		return relativePath
+	}
+	full := path.Join(group.BasePath(), relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(full, "/") {
+		full += "/"
 	}
+	return full
 }
 
 func (g *Ginx) StaticFile(s string, s2 string) Router {
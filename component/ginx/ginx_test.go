@@ -0,0 +1,49 @@
+package ginx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goslacker/slacker/core/app"
+	"github.com/goslacker/slacker/core/container"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGinx_Init(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	container.Set(container.NewContainer())
+	defer container.Set(nil)
+
+	t.Run("invalid config", func(t *testing.T) {
+		require.NoError(t, app.LoadConfig(app.WithContent(`
+ginx:
+  lts: true
+  keyFile: server.key
+  openapi:
+    path: docs
+`)))
+		err := NewGinx().Init()
+		var confErr *app.ConfigError
+		require.True(t, errors.As(err, &confErr))
+		fields := make([]string, 0, len(confErr.Fields))
+		for _, f := range confErr.Fields {
+			fields = append(fields, f.Field)
+		}
+		require.ElementsMatch(t, []string{"addr", "certfile", "openapi.path"}, fields)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		require.NoError(t, app.LoadConfig(app.WithContent(`
+ginx:
+  addr: 127.0.0.1:0
+`)))
+		g := NewGinx()
+		require.NoError(t, g.Init())
+		require.Equal(t, config{
+			Addr:    "127.0.0.1:0",
+			OpenAPI: openAPIConfig{Path: "/openapi", Title: "API", Version: "1.0.0"},
+		}, g.conf)
+		require.Nil(t, g.OpenAPI())
+	})
+}
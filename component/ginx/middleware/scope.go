@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/goslacker/slacker/core/container"
+)
+
+// Scope 为每个请求创建作用域子容器, 请求结束后释放, 后续中间件可以通过 container.BindContext(c.Request.Context(), ...) 绑定请求级别的对象
+func Scope(c *gin.Context) {
+	if _, ok := container.ScopeFromContext(c.Request.Context()); ok {
+		c.Next()
+		return
+	}
+	ctx, scope := container.Scope(c.Request.Context())
+	defer scope.Close()
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
@@ -0,0 +1,427 @@
+package ginx
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OpenAPI 文档, 只包含生成时用到的字段
+type OpenAPI struct {
+	OpenAPI    string                                 `json:"openapi" yaml:"openapi"`
+	Info       OpenAPIInfo                            `json:"info" yaml:"info"`
+	Paths      map[string]map[string]OpenAPIOperation `json:"paths" yaml:"paths"`
+	Components OpenAPIComponents                      `json:"components" yaml:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas" yaml:"schemas"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses" yaml:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name" yaml:"name"`
+	In       string         `json:"in" yaml:"in"` // path 或 query
+	Required bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Style    string         `json:"style,omitempty" yaml:"style,omitempty"`
+	Explode  *bool          `json:"explode,omitempty" yaml:"explode,omitempty"`
+	Schema   *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content" yaml:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description" yaml:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+}
+
+// route 注册时记录的路由, 生成文档时再解析处理函数的类型
+type route struct {
+	method  string
+	path    string
+	handler any
+}
+
+// openAPI 收集路由, 分组共用一个
+type openAPI struct {
+	info   OpenAPIInfo
+	mu     sync.Mutex
+	routes []route
+}
+
+func (o *openAPI) add(method string, path string, handlers []any) {
+	if o == nil || len(handlers) == 0 {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.routes = append(o.routes, route{method: method, path: path, handler: handlers[len(handlers)-1]})
+}
+
+var (
+	pathParamPattern  = regexp.MustCompile(`[:*]([^/]+)`)
+	schemaNamePattern = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+	ginContextType = reflect.TypeOf((*gin.Context)(nil))
+	pageType       = reflect.TypeOf((*Page)(nil))
+	filtersType    = reflect.TypeOf((*Filters)(nil))
+	sortsType      = reflect.TypeOf((*Sorts)(nil))
+	claimsType     = reflect.TypeOf(jwt.MapClaims(nil))
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+	responseType   = reflect.TypeOf((*Response)(nil)).Elem()
+	fileType       = reflect.TypeOf((*File)(nil)).Elem()
+	metaType       = reflect.TypeOf(Meta{})
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// Document 生成 OpenAPI 3 文档
+func (o *openAPI) Document() *OpenAPI {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b := &schemaBuilder{schemas: make(map[string]*OpenAPISchema), names: make(map[reflect.Type]string)}
+	doc := &OpenAPI{
+		OpenAPI: "3.0.3",
+		Info:    o.info,
+		Paths:   make(map[string]map[string]OpenAPIOperation),
+	}
+	for _, r := range o.routes {
+		path := pathParamPattern.ReplaceAllString(r.path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]OpenAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(r.method)] = b.operation(r)
+	}
+	doc.Components.Schemas = b.schemas
+	return doc
+}
+
+type schemaBuilder struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+func (b *schemaBuilder) operation(r route) OpenAPIOperation {
+	op := OpenAPIOperation{
+		OperationID: operationID(r.method, r.path),
+		Responses: map[string]OpenAPIResponse{
+			"default": {Description: "error", Content: jsonContent(b.schema(reflect.TypeOf(ErrorJsonResponse{})))},
+		},
+	}
+
+	// 路径参数默认为 string, 参数结构体中有 uri 标签时使用字段的类型
+	pathParams := make(map[string]*OpenAPISchema)
+	var pathNames []string
+	for _, m := range pathParamPattern.FindAllStringSubmatch(r.path, -1) {
+		pathNames = append(pathNames, m[1])
+		pathParams[m[1]] = &OpenAPISchema{Type: "string"}
+	}
+
+	var body *OpenAPISchema
+	fType := reflect.TypeOf(r.handler)
+	if fType == nil || fType.Kind() != reflect.Func {
+		return op
+	}
+	for i := 0; i < fType.NumIn(); i++ {
+		in := fType.In(i)
+		switch in {
+		case ginContextType, claimsType:
+		case pageType:
+			op.Parameters = append(op.Parameters,
+				OpenAPIParameter{Name: "page", In: "query", Schema: &OpenAPISchema{Type: "integer"}},
+				OpenAPIParameter{Name: "size", In: "query", Schema: &OpenAPISchema{Type: "integer"}},
+			)
+		case filtersType, sortsType:
+			name := "filters"
+			if in == sortsType {
+				name = "sorts"
+			}
+			explode := true
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name: name, In: "query", Style: "deepObject", Explode: &explode,
+				Schema: &OpenAPISchema{Type: "object", AdditionalProperties: &OpenAPISchema{Type: "string"}},
+			})
+		default:
+			t := indirect(in)
+			if t.Kind() != reflect.Struct {
+				continue
+			}
+			var query []OpenAPIParameter
+			query, body = b.request(t, r.method, pathParams)
+			op.Parameters = append(op.Parameters, query...)
+		}
+	}
+	for _, name := range pathNames {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{Name: name, In: "path", Required: true, Schema: pathParams[name]})
+	}
+	sort.SliceStable(op.Parameters, func(i, j int) bool {
+		return op.Parameters[i].In == "path" && op.Parameters[j].In != "path"
+	})
+	if body != nil {
+		op.RequestBody = &OpenAPIRequestBody{Required: true, Content: jsonContent(body)}
+	}
+
+	op.Responses["200"] = b.response(fType)
+	return op
+}
+
+// request 按 gin 的绑定规则拆分参数结构体: uri 标签为路径参数, form 标签为查询参数,
+// 没有请求体的方法其他字段也是查询参数, 否则组成 json 请求体
+func (b *schemaBuilder) request(t reflect.Type, method string, pathParams map[string]*OpenAPISchema) (query []OpenAPIParameter, body *OpenAPISchema) {
+	hasBody := method != http.MethodGet && method != http.MethodHead
+	body = &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	partial := false
+	eachField(t, func(f reflect.StructField) {
+		if name := tagName(f, "uri"); name != "" {
+			if _, ok := pathParams[name]; ok {
+				pathParams[name] = b.schema(f.Type)
+			}
+			partial = true
+			return
+		}
+		if name := tagName(f, "form"); name != "" || !hasBody {
+			if name == "" {
+				name = f.Name
+			}
+			query = append(query, OpenAPIParameter{Name: name, In: "query", Required: hasRule(f, "required"), Schema: b.property(f)})
+			partial = true
+			return
+		}
+		b.addProperty(body, f)
+	})
+	if len(body.Properties) == 0 {
+		return query, nil
+	}
+	// 整个结构体都是请求体时引用其定义, 否则只包含请求体的字段
+	if !partial {
+		return query, b.schema(t)
+	}
+	return query, body
+}
+
+// response 处理函数的返回值, 与 fromResults 一致, 数据放在 data 中
+func (b *schemaBuilder) response(fType reflect.Type) OpenAPIResponse {
+	for i := 0; i < fType.NumOut(); i++ {
+		out := fType.Out(i)
+		switch {
+		case out == metaType, out.Kind() == reflect.Int, out.Kind() == reflect.Bool, out.Implements(errorType):
+			continue
+		case out.Implements(fileType):
+			return OpenAPIResponse{Description: "file", Content: map[string]OpenAPIMediaType{
+				"application/octet-stream": {Schema: &OpenAPISchema{Type: "string", Format: "binary"}},
+			}}
+		case out.Implements(responseType):
+			return OpenAPIResponse{Description: "response"}
+		}
+		return OpenAPIResponse{Description: "success", Content: jsonContent(&OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*OpenAPISchema{
+				"data": b.schema(out),
+				"meta": {Type: "object"},
+			},
+		})}
+	}
+	return OpenAPIResponse{Description: "success", Content: jsonContent(&OpenAPISchema{
+		Type:       "object",
+		Properties: map[string]*OpenAPISchema{"data": {Nullable: true}},
+	})}
+}
+
+// schema 类型对应的 schema, 结构体定义在 components 中并返回引用
+func (b *schemaBuilder) schema(t reflect.Type) *OpenAPISchema {
+	if t.Kind() == reflect.Pointer {
+		s := b.schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	}
+	switch {
+	case t == timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &OpenAPISchema{Type: "string", Format: "byte"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if name, ok := b.names[t]; ok {
+			return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+		}
+		s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+		ref := b.define(t, s)
+		eachField(t, func(f reflect.StructField) {
+			b.addProperty(s, f)
+		})
+		return ref
+	default:
+		return &OpenAPISchema{}
+	}
+}
+
+// addProperty 把字段加入对象的属性, 名称取 json 标签
+func (b *schemaBuilder) addProperty(s *OpenAPISchema, f reflect.StructField) {
+	name := tagName(f, "json")
+	if name == "" {
+		name = f.Name
+	}
+	s.Properties[name] = b.property(f)
+	if hasRule(f, "required") {
+		s.Required = append(s.Required, name)
+	}
+}
+
+// property 字段的 schema, binding 标签的 oneof 作为枚举
+func (b *schemaBuilder) property(f reflect.StructField) *OpenAPISchema {
+	s := b.schema(f.Type)
+	if options, ok := rule(f, "oneof"); ok && s.Ref == "" {
+		s.Enum = strings.Fields(options)
+	}
+	return s
+}
+
+// define 以类型名定义 schema, 名称冲突时加上包名
+func (b *schemaBuilder) define(t reflect.Type, s *OpenAPISchema) *OpenAPISchema {
+	name, ok := b.names[t]
+	if !ok {
+		name = schemaNamePattern.ReplaceAllString(t.Name(), "_")
+		if name == "" {
+			name = "Anonymous"
+		}
+		if _, exists := b.schemas[name]; exists {
+			pkg := t.PkgPath()
+			name = schemaNamePattern.ReplaceAllString(pkg[strings.LastIndex(pkg, "/")+1:], "_") + "." + name
+		}
+		for base, i := name, 2; b.schemas[name] != nil; i++ {
+			name = base + "_" + strconv.Itoa(i)
+		}
+		b.names[t] = name
+	}
+	b.schemas[name] = s
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+// eachField 遍历导出字段, 展开匿名结构体, 跳过 json:"-"
+func eachField(t reflect.Type, f func(reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		if field.Anonymous && tagName(field, "json") == "" && indirect(field.Type).Kind() == reflect.Struct {
+			eachField(indirect(field.Type), f)
+			continue
+		}
+		f(field)
+	}
+}
+
+func tagName(f reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// rule binding 标签中的规则, 只看顶层规则, dive 之后的属于元素
+func rule(f reflect.StructField, name string) (param string, ok bool) {
+	for _, r := range strings.Split(f.Tag.Get("binding"), ",") {
+		if r == "dive" {
+			return "", false
+		}
+		key, value, _ := strings.Cut(r, "=")
+		if key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func hasRule(f reflect.StructField, name string) bool {
+	_, ok := rule(f, name)
+	return ok
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func jsonContent(s *OpenAPISchema) map[string]OpenAPIMediaType {
+	return map[string]OpenAPIMediaType{"application/json": {Schema: s}}
+}
+
+// operationID 如 GET /users/:id 为 getUsersById
+func operationID(method string, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '-' || r == '_' || r == '.'
+	}) {
+		if part[0] == ':' || part[0] == '*' {
+			sb.WriteString("By")
+			part = part[1:]
+		}
+		if part == "" {
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
@@ -0,0 +1,144 @@
+package ginx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goslacker/slacker/core/app"
+	"github.com/goslacker/slacker/core/container"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type userDTO struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Tags      []string  `json:"tags"`
+	Manager   *userDTO  `json:"manager"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type updateUserRequest struct {
+	ID     uint64 `uri:"id"`
+	Notify bool   `form:"notify"`
+	Name   string `json:"name" binding:"required"`
+	Role   string `json:"role" binding:"oneof=admin member"`
+}
+
+type searchUserRequest struct {
+	Keyword string `form:"keyword" binding:"required"`
+	Role    string
+}
+
+func TestGinx_OpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	container.Set(container.NewContainer())
+	defer container.Set(nil)
+	require.NoError(t, app.LoadConfig(app.WithContent(`
+ginx:
+  addr: 127.0.0.1:0
+  openapi:
+    enabled: true
+    title: user service
+`)))
+	g := NewGinx()
+	require.NoError(t, g.Init())
+
+	api := g.Group("/api/v1")
+	api.GET("/users", func(req *searchUserRequest, page *Page, sorts *Sorts) ([]*userDTO, Meta, error) {
+		return nil, nil, nil
+	})
+	api.PUT("/users/:id", func(req updateUserRequest) (*userDTO, error) {
+		return nil, nil
+	})
+	api.DELETE("/users/:id", func(ctx *gin.Context) error {
+		return nil
+	})
+	api.POST("/users", func(req *userDTO) (int, error) {
+		return http.StatusCreated, nil
+	})
+
+	doc := g.OpenAPI()
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Equal(t, OpenAPIInfo{Title: "user service", Version: "1.0.0"}, doc.Info)
+	require.Len(t, doc.Paths, 2)
+
+	t.Run("query parameters", func(t *testing.T) {
+		op := doc.Paths["/api/v1/users"]["get"]
+		require.Equal(t, "getApiV1Users", op.OperationID)
+		names := make([]string, 0, len(op.Parameters))
+		for _, p := range op.Parameters {
+			require.Equal(t, "query", p.In)
+			names = append(names, p.Name)
+		}
+		require.Equal(t, []string{"keyword", "Role", "page", "size", "sorts"}, names)
+		require.True(t, op.Parameters[0].Required)
+		require.Equal(t, "deepObject", op.Parameters[4].Style)
+		require.Nil(t, op.RequestBody)
+
+		data := op.Responses["200"].Content["application/json"].Schema.Properties["data"]
+		require.Equal(t, "array", data.Type)
+		require.Equal(t, "#/components/schemas/userDTO", data.Items.Ref)
+	})
+
+	t.Run("path parameters and body", func(t *testing.T) {
+		op := doc.Paths["/api/v1/users/{id}"]["put"]
+		require.Equal(t, []OpenAPIParameter{
+			{Name: "id", In: "path", Required: true, Schema: &OpenAPISchema{Type: "integer", Format: "int64"}},
+			{Name: "notify", In: "query", Schema: &OpenAPISchema{Type: "boolean"}},
+		}, op.Parameters)
+		body := op.RequestBody.Content["application/json"].Schema
+		require.Equal(t, []string{"name"}, body.Required)
+		require.Equal(t, []string{"admin", "member"}, body.Properties["role"].Enum)
+		require.Len(t, body.Properties, 2)
+
+		op = doc.Paths["/api/v1/users/{id}"]["delete"]
+		require.Equal(t, []OpenAPIParameter{{Name: "id", In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"}}}, op.Parameters)
+		require.Equal(t, "#/components/schemas/ErrorJsonResponse", op.Responses["default"].Content["application/json"].Schema.Ref)
+	})
+
+	t.Run("components", func(t *testing.T) {
+		op := doc.Paths["/api/v1/users"]["post"]
+		require.Equal(t, "#/components/schemas/userDTO", op.RequestBody.Content["application/json"].Schema.Ref)
+
+		user := doc.Components.Schemas["userDTO"]
+		require.Equal(t, "#/components/schemas/userDTO", user.Properties["manager"].Ref)
+		require.Equal(t, &OpenAPISchema{Type: "string", Format: "date-time"}, user.Properties["created_at"])
+		require.Equal(t, "array", doc.Components.Schemas["ErrorJsonResponse"].Properties["field_errors"].Type)
+		require.Contains(t, doc.Components.Schemas, "FieldError")
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		handler := g.router.(http.Handler)
+		get := func(path string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			return w
+		}
+
+		var fromJSON map[string]any
+		require.NoError(t, json.Unmarshal(get("/openapi.json").Body.Bytes(), &fromJSON))
+		require.Contains(t, fromJSON["paths"], "/api/v1/users/{id}")
+
+		var fromYAML map[string]any
+		require.NoError(t, yaml.Unmarshal(get("/openapi.yaml").Body.Bytes(), &fromYAML))
+		require.Equal(t, fromJSON["info"], fromYAML["info"])
+		require.Contains(t, fromYAML["paths"], "/api/v1/users")
+
+		w := get("/openapi")
+		require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		require.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+		require.Contains(t, w.Body.String(), "<title>user service</title>")
+		require.Contains(t, w.Body.String(), `href="/openapi/swagger-ui.css"`)
+		require.Contains(t, w.Body.String(), `src="/openapi/swagger-ui-bundle.js"`)
+
+		require.Contains(t, get("/openapi/swagger-ui.css").Header().Get("Content-Type"), "text/css")
+		require.Contains(t, get("/openapi/swagger-ui-bundle.js").Body.String(), "SwaggerUIBundle")
+	})
+}
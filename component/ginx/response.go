@@ -118,6 +118,10 @@ func ResponseFromError(err error) *ErrorJsonResponse {
 		StatusCode: http.StatusInternalServerError,
 	}
 	switch x := err.(type) {
+	case *BindError:
+		r.StatusCode = x.StatusCode
+		r.Message = http.StatusText(x.StatusCode)
+		r.FieldErrors = x.FieldErrors
 	case *errx.Error:
 		r.Message = x.Error()
 		if http.StatusText(x.Code) == "" {
@@ -138,11 +142,12 @@ func ResponseFromError(err error) *ErrorJsonResponse {
 }
 
 type ErrorJsonResponse struct {
-	Message    string         `json:"message"`
-	Detail     map[string]any `json:"detail,omitempty"`
-	Code       *int           `json:"code,omitempty"`
-	StatusCode int            `json:"-"`
-	Abort      bool           `json:"-"`
+	Message     string         `json:"message"`
+	Detail      map[string]any `json:"detail,omitempty"`
+	FieldErrors []FieldError   `json:"field_errors,omitempty"` // 请求参数错误, 与 grpcx.ErrorDetail 的 field_errors 一致
+	Code        *int           `json:"code,omitempty"`
+	StatusCode  int            `json:"-"`
+	Abort       bool           `json:"-"`
 }
 
 func (er *ErrorJsonResponse) Do(ctx *gin.Context) {
@@ -0,0 +1,66 @@
+package ginx
+
+import (
+	"embed"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// swaggerFS Swagger UI 页面及 swagger-ui-dist 5.18.2 的样式和脚本, 不依赖外部 CDN
+//
+//go:embed swagger
+var swaggerFS embed.FS
+
+var swaggerTemplate = template.Must(template.ParseFS(swaggerFS, "swagger/index.html"))
+
+type openAPIConfig struct {
+	Enabled bool   `mapstructure:"enabled"`                                         // 是否提供文档和 Swagger UI 路由
+	Path    string `mapstructure:"path" default:"/openapi" validate:"startswith=/"` // 文档路由前缀
+	Title   string `mapstructure:"title" default:"API"`                             // 文档标题
+	Version string `mapstructure:"version" default:"1.0.0"`                         // 文档版本
+}
+
+// serveOpenAPI 注册文档路由, {path}.json 和 {path}.yaml 为 OpenAPI 文档, {path} 为 Swagger UI, {path}/ 下为 Swagger UI 的静态文件,
+// 文档在请求时生成, 包含之后注册的路由, 文档路由本身不在文档中
+func (g *Ginx) serveOpenAPI(conf openAPIConfig) {
+	g.docs = &openAPI{info: OpenAPIInfo{
+		Title:   conf.Title,
+		Version: conf.Version,
+	}}
+
+	p := conf.Path
+	g.router.GET(p+".json", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, g.docs.Document())
+	})
+	g.router.GET(p+".yaml", func(ctx *gin.Context) {
+		content, err := yaml.Marshal(g.docs.Document())
+		if err != nil {
+			ResponseFromError(err).Do(ctx)
+			return
+		}
+		ctx.Data(http.StatusOK, "application/yaml; charset=utf-8", content)
+	})
+	g.router.GET(p, func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+		ctx.Header("Content-Type", "text/html; charset=utf-8")
+		_ = swaggerTemplate.Execute(ctx.Writer, map[string]string{
+			"Title":  g.docs.info.Title,
+			"URL":    p + ".json",
+			"Assets": p,
+		})
+	})
+	for _, name := range []string{"swagger-ui.css", "swagger-ui-bundle.js"} {
+		g.router.StaticFileFS(p+"/"+name, "swagger/"+name, http.FS(swaggerFS))
+	}
+}
+
+// OpenAPI 当前路由的 OpenAPI 文档, 未开启 ginx.openapi.enabled 时为 nil
+func (g *Ginx) OpenAPI() *OpenAPI {
+	if g.docs == nil {
+		return nil
+	}
+	return g.docs.Document()
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/goslacker/slacker/core/app"
 	"github.com/goslacker/slacker/core/container"
 	"github.com/goslacker/slacker/core/errx"
 	"github.com/goslacker/slacker/core/reflectx"
@@ -35,6 +34,13 @@ func WrapMiddleware(f any, opts ...func(*handlerOpts)) gin.HandlerFunc {
 	}
 
 	return func(ctx *gin.Context) {
+		// 未经过 middleware.Scope 时在这里创建请求作用域
+		if _, ok := container.ScopeFromContext(ctx.Request.Context()); !ok {
+			scopeCtx, scope := container.Scope(ctx.Request.Context())
+			defer scope.Close()
+			ctx.Request = ctx.Request.WithContext(scopeCtx)
+		}
+
 		params, err := buildParams(fType, ctx)
 		if err != nil {
 			response := ResponseFromError(err)
@@ -70,6 +76,13 @@ func WrapEndpoint(f any, opts ...func(*handlerOpts)) gin.HandlerFunc {
 	}
 
 	return func(ctx *gin.Context) {
+		// 未经过 middleware.Scope 时在这里创建请求作用域
+		if _, ok := container.ScopeFromContext(ctx.Request.Context()); !ok {
+			scopeCtx, scope := container.Scope(ctx.Request.Context())
+			defer scope.Close()
+			ctx.Request = ctx.Request.WithContext(scopeCtx)
+		}
+
 		params, err := buildParams(fType, ctx)
 		if err != nil {
 			response := ResponseFromError(err)
@@ -119,25 +132,49 @@ func buildParams(fType reflect.Type, ctx *gin.Context) (params []reflect.Value,
 }
 
 func parseParam(ctx *gin.Context, t reflect.Type) (p reflect.Value, err error) {
-	p, err = app.GetContainer().Resolve(t, "")
+	p, err = container.FromContext(ctx.Request.Context()).Resolve(t, "")
 
 	if err != nil && !errors.Is(err, container.ErrNotFound) {
 		return
 	}
 
 	if err != nil || !p.IsValid() {
+		setupValidator()
 		psrc := reflectx.NewIndirectType(t)
 
+		var bindErr *BindError
+		collect := func(err error) {
+			if err == nil || isValidationErr(err) {
+				return
+			}
+			e := newBindError(ctx, err)
+			if e == nil {
+				return
+			}
+			if bindErr == nil {
+				bindErr = e
+				return
+			}
+			// 请求体格式错误优先
+			bindErr.StatusCode = min(bindErr.StatusCode, e.StatusCode)
+			bindErr.FieldErrors = append(bindErr.FieldErrors, e.FieldErrors...)
+		}
 		if len(ctx.Request.URL.Query()) > 0 {
-			_ = ctx.ShouldBindQuery(psrc.Interface())
+			collect(ctx.ShouldBindQuery(psrc.Interface()))
 		}
 		if len(ctx.Params) > 0 {
-			_ = ctx.ShouldBindUri(psrc.Interface())
+			collect(ctx.ShouldBindUri(psrc.Interface()))
+		}
+		collect(ctx.ShouldBind(psrc.Interface()))
+		if bindErr != nil {
+			return p, bindErr
 		}
-		_ = ctx.ShouldBind(psrc.Interface())
 
 		err = binding.Validator.ValidateStruct(psrc.Interface())
 		if err != nil {
+			if e := newBindError(ctx, err); e != nil {
+				return p, e
+			}
 			err = errx.Wrap(err, errx.WithMsg(err.Error()), errx.WithCode(http.StatusUnprocessableEntity))
 			return
 		}
@@ -0,0 +1,123 @@
+package ginx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goslacker/slacker/component/ginx/middleware"
+	"github.com/goslacker/slacker/core/container"
+	"github.com/stretchr/testify/require"
+)
+
+type requestSession struct {
+	user   string
+	closed bool
+}
+
+func (s *requestSession) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestWrapEndpoint_Scope(t *testing.T) {
+	var sessions []*requestSession
+	require.NoError(t, container.Bind[*requestSession](func() *requestSession {
+		s := &requestSession{}
+		sessions = append(sessions, s)
+		return s
+	}, container.Scoped()))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Scope, func(c *gin.Context) {
+		s, err := container.ResolveContext[*requestSession](c.Request.Context())
+		require.NoError(t, err)
+		s.user = c.GetHeader("X-User")
+		c.Next()
+	})
+	r.GET("/me", WrapEndpoint(func(s *requestSession) (string, error) {
+		require.False(t, s.closed)
+		return s.user, nil
+	}))
+
+	for _, user := range []string{"tom", "jerry"} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), user)
+	}
+	require.Len(t, sessions, 2)
+	require.True(t, sessions[0].closed)
+	require.True(t, sessions[1].closed)
+}
+
+type createUserRequest struct {
+	ID      int      `uri:"id"`
+	Verbose bool     `form:"verbose"`
+	Name    string   `json:"name" binding:"required"`
+	Age     int      `json:"age" binding:"gte=18"`
+	Emails  []string `json:"emails" binding:"dive,email"`
+}
+
+func TestWrapEndpoint_BindError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users/:id", WrapEndpoint(func(req *createUserRequest) (*createUserRequest, error) {
+		return req, nil
+	}))
+
+	do := func(path string, body string, lang string) (int, ErrorJsonResponse) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp ErrorJsonResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		code, resp := do("/users/1?verbose=true", `{"name":"tom","age":20}`, "")
+		require.Equal(t, http.StatusOK, code)
+		require.Empty(t, resp.FieldErrors)
+	})
+
+	t.Run("malformed json", func(t *testing.T) {
+		code, resp := do("/users/1", `{"name":`, "zh-CN,zh;q=0.9")
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, "Bad Request", resp.Message)
+		require.Len(t, resp.FieldErrors, 1)
+		require.Equal(t, "json", resp.FieldErrors[0].Rule)
+		require.Equal(t, "请求体不是有效的 JSON", resp.FieldErrors[0].Localized)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		code, resp := do("/users/1", `{"name":"tom","age":"old"}`, "")
+		require.Equal(t, http.StatusUnprocessableEntity, code)
+		require.Equal(t, []FieldError{{Field: "age", Rule: "type", Message: "must be a valid int", Localized: "must be a valid int"}}, resp.FieldErrors)
+
+		code, resp = do("/users/abc", `{"name":"tom","age":20}`, "")
+		require.Equal(t, http.StatusUnprocessableEntity, code)
+		require.Equal(t, "type", resp.FieldErrors[0].Rule)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		code, resp := do("/users/1", `{"age":10,"emails":["a@b.com","bad"]}`, "zh")
+		require.Equal(t, http.StatusUnprocessableEntity, code)
+		require.Equal(t, "Unprocessable Entity", resp.Message)
+		require.Equal(t, []FieldError{
+			{Field: "name", Rule: "required", Message: "name is a required field", Localized: "name为必填字段"},
+			{Field: "age", Rule: "gte", Message: "age must be 18 or greater", Localized: "age必须大于或等于18"},
+			{Field: "emails[1]", Rule: "email", Message: "emails[1] must be a valid email address", Localized: "emails[1]必须是一个有效的邮箱"},
+		}, resp.FieldErrors)
+	})
+}
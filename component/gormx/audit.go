@@ -0,0 +1,74 @@
+package gormx
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/goslacker/slacker/core/jwtx"
+	"gorm.io/gorm"
+)
+
+// auditPlugin 为嵌入 AuditField 的模型填充 created_by 和 updated_by, 上下文中没有 jwt claims 时不填充
+type auditPlugin struct {
+	claim string
+}
+
+func (p *auditPlugin) Name() string {
+	return "gormx:audit"
+}
+
+func (p *auditPlugin) Initialize(db *gorm.DB) (err error) {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("gormx:audit_create", p.create),
+		cb.Update().Before("gorm:update").Register("gormx:audit_update", p.update),
+	)
+}
+
+// operator 当前操作人, 模型没有嵌入 AuditField 或上下文中没有对应的 claim 时返回空
+func (p *auditPlugin) operator(tx *gorm.DB) string {
+	if tx.Statement.Schema == nil {
+		return ""
+	}
+	if _, ok := reflect.New(tx.Statement.Schema.ModelType).Interface().(audited); !ok {
+		return ""
+	}
+	operator, err := jwtx.FieldFromContext[string](tx.Statement.Context, p.claim)
+	if err != nil {
+		return ""
+	}
+	return operator
+}
+
+func (p *auditPlugin) create(tx *gorm.DB) {
+	operator := p.operator(tx)
+	if operator == "" {
+		return
+	}
+	s := tx.Statement.Schema
+	createdBy, updatedBy := s.LookUpField("CreatedBy"), s.LookUpField("UpdatedBy")
+	fill := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		// upsert 更新已有记录时保留原来的创建人
+		if _, zero := createdBy.ValueOf(tx.Statement.Context, rv); zero {
+			tx.AddError(createdBy.Set(tx.Statement.Context, rv, operator))
+		}
+		tx.AddError(updatedBy.Set(tx.Statement.Context, rv, operator))
+	}
+	switch rv := tx.Statement.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fill(rv.Index(i))
+		}
+	case reflect.Struct:
+		fill(rv)
+	}
+}
+
+func (p *auditPlugin) update(tx *gorm.DB) {
+	operator := p.operator(tx)
+	if operator == "" {
+		return
+	}
+	tx.Statement.SetColumn("UpdatedBy", operator)
+}
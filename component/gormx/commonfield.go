@@ -38,3 +38,30 @@ func (s *SnowflakeID) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+// Version 乐观锁版本号, 嵌入后 HolderRepository 的 Save 和 Update 只在版本一致时更新, 否则返回 ErrConcurrentUpdate
+type Version struct {
+	Version int64 `json:"version" gorm:"not null;default:0"`
+}
+
+func (v *Version) versionField() *int64 {
+	return &v.Version
+}
+
+type versioned interface {
+	versionField() *int64
+}
+
+// AuditField 嵌入后创建和更新时从上下文的 jwt claims 中填充操作人, 见 gormx.audit_claim 配置
+type AuditField struct {
+	CreatedBy string `json:"created_by" gorm:"size:64"`
+	UpdatedBy string `json:"updated_by" gorm:"size:64"`
+}
+
+func (a *AuditField) auditField() *AuditField {
+	return a
+}
+
+type audited interface {
+	auditField() *AuditField
+}
@@ -1,16 +1,21 @@
 package gormx
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/goslacker/slacker/core/app"
+	"github.com/goslacker/slacker/core/container"
 	"github.com/goslacker/slacker/core/database"
+	"github.com/goslacker/slacker/core/lock"
 	"github.com/sony/sonyflake"
-	"log"
-	"os"
-	"time"
+	"go.opentelemetry.io/otel"
 
-	"github.com/spf13/viper"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -19,56 +24,166 @@ func NewComponent() *Component {
 	return &Component{}
 }
 
+// poolConfig 连接池配置, 主库和副本使用相同的配置
+type poolConfig struct {
+	MaxOpenConns    int           `mapstructure:"max_open_conns" default:"100" validate:"gte=0"` // 最大连接数, 0 不限制
+	MaxIdleConns    int           `mapstructure:"max_idle_conns" default:"10" validate:"gte=0"`  // 最大空闲连接数
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime" default:"1h" validate:"gte=0"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time" default:"10m" validate:"gte=0"`
+}
+
+func (p poolConfig) apply(sqlDB *sql.DB) {
+	sqlDB.SetMaxOpenConns(p.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(p.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(p.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+}
+
+// poolOverride 连接单独的连接池配置, 只覆盖设置了的项, 其他使用 gormx.pool
+type poolOverride struct {
+	MaxOpenConns    *int           `mapstructure:"max_open_conns" validate:"omitnil,gte=0"`
+	MaxIdleConns    *int           `mapstructure:"max_idle_conns" validate:"omitnil,gte=0"`
+	ConnMaxLifetime *time.Duration `mapstructure:"conn_max_lifetime" validate:"omitnil,gte=0"`
+	ConnMaxIdleTime *time.Duration `mapstructure:"conn_max_idle_time" validate:"omitnil,gte=0"`
+}
+
+func (o poolOverride) merge(p poolConfig) poolConfig {
+	if o.MaxOpenConns != nil {
+		p.MaxOpenConns = *o.MaxOpenConns
+	}
+	if o.MaxIdleConns != nil {
+		p.MaxIdleConns = *o.MaxIdleConns
+	}
+	if o.ConnMaxLifetime != nil {
+		p.ConnMaxLifetime = *o.ConnMaxLifetime
+	}
+	if o.ConnMaxIdleTime != nil {
+		p.ConnMaxIdleTime = *o.ConnMaxIdleTime
+	}
+	return p
+}
+
+// connectionConfig 一个数据库连接, Replicas 为只读副本的 DSN
+type connectionConfig struct {
+	DSN      app.Secret   `mapstructure:"dsn" validate:"required"`
+	Replicas []app.Secret `mapstructure:"replicas"`
+	Pool     poolOverride `mapstructure:"pool"`
+}
+
+// lockConfig 启动迁移和种子数据使用的分布式锁, type 为空时 mysql 和 postgres 使用 db, 其他使用 memory
+type lockConfig struct {
+	Type      string   `mapstructure:"type" validate:"omitempty,oneof=memory db etcd"`
+	Endpoints []string `mapstructure:"endpoints" validate:"required_if=Type etcd"` // etcd 地址
+}
+
+// config gormx 配置, dsn 和 replicas 为默认连接, connections 为按名称绑定的其他连接
+type config struct {
+	DSN          app.Secret                  `mapstructure:"dsn" validate:"required"`
+	Replicas     []app.Secret                `mapstructure:"replicas"`
+	Connections  map[string]connectionConfig `mapstructure:"connections" validate:"dive"`
+	Pool         poolConfig                  `mapstructure:"pool"`
+	Metrics      metricsConfig               `mapstructure:"metrics"`
+	Tracing      bool                        `mapstructure:"tracing" default:"true"`    // 为每条语句创建链路追踪的子 span
+	AuditClaim   string                      `mapstructure:"audit_claim" default:"sub"` // 填充 AuditField 操作人的 jwt claim
+	Logger       loggerConfig                `mapstructure:"logger"`
+	Lock         lockConfig                  `mapstructure:"lock"`
+	ReplicaCheck time.Duration               `mapstructure:"replica_check" default:"10s" validate:"gt=0"` // 副本健康检查间隔
+}
+
 type Component struct {
 	app.Component
+	sqlDBs  map[string]*sql.DB
+	metrics *dbMetrics
+	tracing bool
+	audit   *auditPlugin
 }
 
-func (c *Component) Init() (err error) {
-	conf := viper.Sub("gormx")
-	mysqlLogger := conf.GetStringMap("logger")
-	// 默认配置
-	defaultLogger := map[string]interface{}{
-		"slow_threshold":                200,
-		"log_level":                     4,
-		"ignore_record_not_found_error": true,
-		"colorful":                      true,
-		"parameterized_queries":         false,
-	}
-
-	// 检查是否有缺失配置
-	for key, value := range defaultLogger {
-		if _, exists := mysqlLogger[key]; !exists {
-			mysqlLogger[key] = value
+func (c *Component) Name() string {
+	return "gormx"
+}
+
+// Ready 所有连接的主库都可以ping通时就绪
+func (c *Component) Ready() error {
+	if len(c.sqlDBs) == 0 {
+		return errors.New("database is not initialized")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	for name, sqlDB := range c.sqlDBs {
+		if err := sqlDB.PingContext(ctx); err != nil {
+			if name == "" {
+				return err
+			}
+			return fmt.Errorf("connection <%s>: %w", name, err)
 		}
 	}
+	return nil
+}
 
-	slowThreshold := mysqlLogger["slow_threshold"].(int)
-	logLevel := mysqlLogger["log_level"].(int)
-	ignoreRecordNotFoundError := mysqlLogger["ignore_record_not_found_error"].(bool)
-	colorful := mysqlLogger["colorful"].(bool)
-	parameterizedQueries := mysqlLogger["parameterized_queries"].(bool)
-	dsn := database.DSN(conf.GetString("dsn"))
-
-	db, err := gorm.Open(mysql.Open(dsn.RemoveSchema()), &gorm.Config{
-		Logger: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
-			SlowThreshold:             time.Duration(slowThreshold) * time.Millisecond,
-			LogLevel:                  logger.LogLevel(logLevel),
-			IgnoreRecordNotFoundError: ignoreRecordNotFoundError,
-			Colorful:                  colorful,
-			ParameterizedQueries:      parameterizedQueries,
-		}),
+func (c *Component) Init() (err error) {
+	conf, err := app.Config[config]("gormx")
+	if err != nil {
+		return
+	}
+
+	dbLogger := newReloadableLogger(newLogger(conf.Logger))
+
+	// 日志配置热更新
+	app.OnConfigChange("gormx.logger", func(event app.ConfigChanged) (err error) {
+		conf, err := app.Config[loggerConfig]("gormx.logger")
+		if err != nil {
+			return
+		}
+		dbLogger.Store(newLogger(conf))
+		return
 	})
+
+	if conf.Metrics.Enabled {
+		c.metrics, err = newDBMetrics(otel.Meter(meterName), conf.Metrics.SlowThreshold)
+		if err != nil {
+			return fmt.Errorf("create database metrics failed: %w", err)
+		}
+	}
+
+	c.tracing = conf.Tracing
+	c.audit = &auditPlugin{claim: conf.AuditClaim}
+
+	c.sqlDBs = make(map[string]*sql.DB, len(conf.Connections)+1)
+	err = c.bindConnection("", connectionConfig{DSN: conf.DSN, Replicas: conf.Replicas}, conf.Pool, dbLogger, conf.ReplicaCheck)
 	if err != nil {
 		return
 	}
+	names := make([]string, 0, len(conf.Connections))
+	for name := range conf.Connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		connConf := conf.Connections[name]
+		err = c.bindConnection(name, connConf, connConf.Pool.merge(conf.Pool), dbLogger, conf.ReplicaCheck)
+		if err != nil {
+			return fmt.Errorf("init connection <%s> failed: %w", name, err)
+		}
+	}
 
-	err = app.Bind[*gorm.DB](db)
+	err = app.Bind[lock.Locker](func(db *sql.DB) (lock.Locker, error) {
+		dialect, _, _ := strings.Cut(conf.DSN.Reveal(), "://")
+		typ := conf.Lock.Type
+		if typ == "" {
+			typ = "memory"
+			if _, err := lock.NewDBLocker(db, dialect); err == nil {
+				typ = "db"
+			}
+		}
+		return lock.BuildLocker(typ, db, dialect, conf.Lock.Endpoints)
+	})
 	if err != nil {
 		return
 	}
 
-	sqlDb, _ := db.DB()
-	err = app.Bind[*sql.DB](sqlDb)
+	err = app.Bind[*Outbox](func(db *gorm.DB) *Outbox {
+		return NewOutbox(db)
+	})
 	if err != nil {
 		return
 	}
@@ -81,3 +196,88 @@ func (c *Component) Init() (err error) {
 	}
 	return
 }
+
+// bindConnection 绑定 *gorm.DB, *sql.DB 和 *DB, name 不为空时以 name 为 key 绑定, 可以通过 container.WithKey 或 inject 标签解析
+func (c *Component) bindConnection(name string, conf connectionConfig, pool poolConfig, dbLogger logger.Interface, replicaCheck time.Duration) (err error) {
+	var opts []func(*container.BindOpts)
+	if name != "" {
+		opts = append(opts, container.WithKey(name))
+	}
+
+	poolName := name
+	if poolName == "" {
+		poolName = "default"
+	}
+	db, err := c.open(poolName, conf.DSN, pool, dbLogger)
+	if err != nil {
+		return
+	}
+	err = app.Bind[*gorm.DB](db, opts...)
+	if err != nil {
+		return
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	err = app.Bind[*sql.DB](sqlDB, opts...)
+	if err != nil {
+		return
+	}
+	c.sqlDBs[name] = sqlDB
+
+	var holderOpts []func(*DB)
+	if len(conf.Replicas) > 0 {
+		dbs := make([]*gorm.DB, 0, len(conf.Replicas))
+		for i, dsn := range conf.Replicas {
+			var replica *gorm.DB
+			replica, err = c.open(fmt.Sprintf("%s.replica.%d", poolName, i), dsn, pool, dbLogger)
+			if err != nil {
+				return fmt.Errorf("open replica %d failed: %w", i, err)
+			}
+			dbs = append(dbs, replica)
+		}
+		replicas := NewReplicas(dbs...)
+		replicas.Watch(replicaCheck)
+		// 绑定后随容器关闭
+		err = app.Bind[*Replicas](replicas, opts...)
+		if err != nil {
+			return
+		}
+		holderOpts = append(holderOpts, WithReplicas(replicas))
+	}
+	return app.Bind[*DB](NewHolder(db, holderOpts...), opts...)
+}
+
+// open 打开连接并设置连接池, 注册链路追踪插件, 开启指标时以 poolName 为连接池名称上报
+func (c *Component) open(poolName string, dsn app.Secret, pool poolConfig, dbLogger logger.Interface) (db *gorm.DB, err error) {
+	dialector, err := Dialector(database.DSN(dsn.Reveal()))
+	if err != nil {
+		return
+	}
+	db, err = gorm.Open(dialector, &gorm.Config{
+		Logger: dbLogger,
+	})
+	if err != nil {
+		return
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	pool.apply(sqlDB)
+	err = db.Use(c.audit)
+	if err != nil {
+		return
+	}
+	if c.tracing {
+		err = db.Use(&tracingPlugin{})
+		if err != nil {
+			return
+		}
+	}
+	if c.metrics != nil {
+		err = c.metrics.watch(poolName, db)
+	}
+	return
+}
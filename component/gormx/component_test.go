@@ -0,0 +1,135 @@
+package gormx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/goslacker/slacker/core/app"
+	"github.com/goslacker/slacker/core/container"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID   uint64
+	Name string
+}
+
+type itemCondition struct {
+	Name string
+}
+
+func parseItemCondition(query *gorm.DB, condition itemCondition) *gorm.DB {
+	if condition.Name != "" {
+		query = query.Where("name = ?", condition.Name)
+	}
+	return query
+}
+
+func TestComponent_Connections(t *testing.T) {
+	container.Set(container.NewContainer())
+	defer container.Set(nil)
+	require.NoError(t, app.LoadConfig(app.WithContent(`
+gormx:
+  dsn: sqlite://file:primary?mode=memory&cache=shared
+  replicas:
+    - sqlite://file:replica?mode=memory&cache=shared
+  pool:
+    max_open_conns: 20
+    max_idle_conns: 3
+  connections:
+    report:
+      dsn: sqlite://file:report?mode=memory&cache=shared
+      pool:
+        max_open_conns: 5
+`)))
+	c := NewComponent()
+	require.NoError(t, c.Init())
+	require.NoError(t, c.Ready())
+
+	primary, err := app.Resolve[*gorm.DB]()
+	require.NoError(t, err)
+	report, err := app.Resolve[*gorm.DB]("report")
+	require.NoError(t, err)
+	require.NotSame(t, primary, report)
+	reportSQL, err := app.Resolve[*sql.DB]("report")
+	require.NoError(t, err)
+	require.Equal(t, 5, reportSQL.Stats().MaxOpenConnections)
+	// 未覆盖的项使用 gormx.pool, 空闲连接可以保留
+	require.NoError(t, reportSQL.Ping())
+	require.Equal(t, 1, reportSQL.Stats().Idle)
+	require.Zero(t, reportSQL.Stats().MaxIdleClosed)
+	maxOpen := 5
+	require.Equal(t, poolConfig{MaxOpenConns: 5, MaxIdleConns: 3, ConnMaxLifetime: time.Hour, ConnMaxIdleTime: 10 * time.Minute},
+		poolOverride{MaxOpenConns: &maxOpen}.merge(poolConfig{MaxOpenConns: 20, MaxIdleConns: 3, ConnMaxLifetime: time.Hour, ConnMaxIdleTime: 10 * time.Minute}))
+	primarySQL, err := app.Resolve[*sql.DB]()
+	require.NoError(t, err)
+	require.Equal(t, 20, primarySQL.Stats().MaxOpenConnections)
+	replicas, err := app.Resolve[*Replicas]()
+	require.NoError(t, err)
+	db, err := app.Resolve[*DB]()
+	require.NoError(t, err)
+
+	// 主库和副本写入不同的数据以区分查询落在哪里
+	require.NoError(t, primary.AutoMigrate(&item{}))
+	require.NoError(t, primary.Create(&item{Name: "primary"}).Error)
+	replica := replicas.Pick()
+	require.NoError(t, replica.AutoMigrate(&item{}))
+	require.NoError(t, replica.Create([]*item{{Name: "replica"}, {Name: "replica"}}).Error)
+
+	repo := NewHolderRepository[item, item, itemCondition](db, parseItemCondition)
+	ctx := context.Background()
+
+	t.Run("reads go to replica", func(t *testing.T) {
+		list, err := repo.List(ctx, itemCondition{})
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		found, err := repo.Find(ctx, itemCondition{Name: "replica"})
+		require.NoError(t, err)
+		require.Equal(t, "replica", found.Name)
+	})
+
+	t.Run("transaction and use primary", func(t *testing.T) {
+		err := db.Transaction(func(ctx context.Context) error {
+			list, err := repo.List(ctx, itemCondition{})
+			require.NoError(t, err)
+			require.Len(t, list, 1)
+			return nil
+		})
+		require.NoError(t, err)
+
+		list, err := repo.List(UsePrimary(ctx), itemCondition{})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+	})
+
+	t.Run("exists and count use primary", func(t *testing.T) {
+		exists, err := repo.Exists(ctx, itemCondition{Name: "replica"})
+		require.NoError(t, err)
+		require.False(t, exists)
+		exists, err = repo.Exists(ctx, itemCondition{Name: "primary"})
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		count, err := repo.Count(ctx, itemCondition{})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, count)
+	})
+
+	t.Run("fallback to primary", func(t *testing.T) {
+		sqlDB, err := replica.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+		replicas.Check(ctx)
+		require.Nil(t, replicas.Pick())
+
+		list, err := repo.List(ctx, itemCondition{})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Equal(t, "primary", list[0].Name)
+	})
+
+	require.NoError(t, container.Default().Close())
+}
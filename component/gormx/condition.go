@@ -0,0 +1,276 @@
+package gormx
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ParseCondition 按结构体标签把条件转换为查询, NewHolderRepository 的 parseCondition 为 nil 时使用.
+//
+//	where:"status"             等于, 切片为 IN
+//	where:"created_at,gte"     支持 eq, ne, gt, gte, lt, lte, like, in, notin, null
+//	where:",or"                结构体字段内的条件用 OR 组合, ",and" 用 AND 组合, 可以嵌套
+//	order:"created_at,id"      字段值为排序, 如 "-created_at" 或 "created_at desc", 只允许标签中的列
+//
+// 列名为空时按命名策略由字段名转换, nil 指针和零值跳过, 指向零值的指针按零值过滤; 没有标签的匿名结构体会展开.
+func ParseCondition[Condition any](query *gorm.DB, condition Condition) *gorm.DB {
+	v := reflect.ValueOf(condition)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return query
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		query.AddError(fmt.Errorf("condition must be struct, got %s", v.Type()))
+		return query
+	}
+	p := &conditionParser{query: query}
+	exprs := p.parse(v)
+	if p.err != nil {
+		query.AddError(p.err)
+		return query
+	}
+	if len(exprs) > 0 {
+		query = query.Where(clause.And(exprs...))
+	}
+	for _, order := range p.orders {
+		query = query.Order(order)
+	}
+	return query
+}
+
+type conditionOp string
+
+const (
+	opEq    conditionOp = "eq"
+	opNe    conditionOp = "ne"
+	opGt    conditionOp = "gt"
+	opGte   conditionOp = "gte"
+	opLt    conditionOp = "lt"
+	opLte   conditionOp = "lte"
+	opLike  conditionOp = "like"
+	opIn    conditionOp = "in"
+	opNotIn conditionOp = "notin"
+	opNull  conditionOp = "null"
+	opOr    conditionOp = "or"
+	opAnd   conditionOp = "and"
+)
+
+// conditionField 条件结构体中一个字段的解析结果
+type conditionField struct {
+	index  int
+	column string
+	op     conditionOp
+	// order 不为nil时字段为排序, 值为允许排序的列
+	order []string
+	// embedded 没有标签的匿名结构体, 展开其中的条件
+	embedded bool
+}
+
+var conditionFields sync.Map // map[reflect.Type][]conditionField
+
+func parseConditionFields(t reflect.Type) (fields []conditionField, err error) {
+	if cached, ok := conditionFields.Load(t); ok {
+		return cached.([]conditionField), nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		where, hasWhere := f.Tag.Lookup("where")
+		order, hasOrder := f.Tag.Lookup("order")
+		switch {
+		case where == "-":
+		case hasOrder:
+			fields = append(fields, conditionField{index: i, order: strings.Split(order, ",")})
+		case hasWhere:
+			if !f.IsExported() {
+				return nil, fmt.Errorf("condition field %s.%s is not exported", t, f.Name)
+			}
+			column, op, _ := strings.Cut(where, ",")
+			field := conditionField{index: i, column: strings.TrimSpace(column), op: conditionOp(strings.TrimSpace(op))}
+			if field.op == "" {
+				field.op = opEq
+			}
+			switch field.op {
+			case opEq, opNe, opGt, opGte, opLt, opLte, opLike, opIn, opNotIn, opNull:
+				if field.column == "" {
+					field.column = f.Name
+				}
+			case opOr, opAnd:
+				if indirectType(f.Type).Kind() != reflect.Struct {
+					return nil, fmt.Errorf("condition group %s.%s must be struct", t, f.Name)
+				}
+			default:
+				return nil, fmt.Errorf("condition field %s.%s has unknown operator <%s>", t, f.Name, field.op)
+			}
+			fields = append(fields, field)
+		case f.Anonymous && indirectType(f.Type).Kind() == reflect.Struct:
+			fields = append(fields, conditionField{index: i, embedded: true})
+		}
+	}
+	conditionFields.Store(t, fields)
+	return
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+type conditionParser struct {
+	query  *gorm.DB
+	orders []clause.OrderByColumn
+	err    error
+}
+
+func (p *conditionParser) parse(v reflect.Value) (exprs []clause.Expression) {
+	fields, err := parseConditionFields(v.Type())
+	if err != nil {
+		p.err = err
+		return
+	}
+	for _, field := range fields {
+		fv := v.Field(field.index)
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			// 指针类型的值不为 nil 即使是零值也作为条件
+			fv = fv.Elem()
+		} else if fv.IsZero() {
+			continue
+		}
+
+		switch {
+		case field.embedded:
+			exprs = append(exprs, p.parse(fv)...)
+		case field.order != nil:
+			p.parseOrder(field, fv)
+		case field.op == opOr || field.op == opAnd:
+			group := p.parse(fv)
+			switch {
+			case len(group) == 0:
+			case len(group) == 1:
+				// 只有一个条件的 clause.Or 会被 gorm 当作 Or() 与前面的条件用 OR 连接
+				exprs = append(exprs, group[0])
+			case field.op == opOr:
+				exprs = append(exprs, clause.Or(group...))
+			default:
+				exprs = append(exprs, clause.And(group...))
+			}
+		default:
+			if expr := p.expression(field, fv); expr != nil {
+				exprs = append(exprs, expr)
+			}
+		}
+		if p.err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p *conditionParser) column(name string) clause.Column {
+	if !strings.Contains(name, ".") && strings.ToLower(name) != name {
+		name = p.query.NamingStrategy.ColumnName("", name)
+	}
+	return clause.Column{Name: name}
+}
+
+func (p *conditionParser) expression(field conditionField, v reflect.Value) clause.Expression {
+	column := p.column(field.column)
+	value := v.Interface()
+	isList := (v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8) || v.Kind() == reflect.Array
+	if isList && v.Len() == 0 {
+		return nil
+	}
+	switch field.op {
+	case opEq, opIn:
+		if isList {
+			return clause.IN{Column: column, Values: listValues(v)}
+		}
+		return clause.Eq{Column: column, Value: value}
+	case opNe, opNotIn:
+		if isList {
+			return clause.Not(clause.IN{Column: column, Values: listValues(v)})
+		}
+		return clause.Neq{Column: column, Value: value}
+	case opGt:
+		return clause.Gt{Column: column, Value: value}
+	case opGte:
+		return clause.Gte{Column: column, Value: value}
+	case opLt:
+		return clause.Lt{Column: column, Value: value}
+	case opLte:
+		return clause.Lte{Column: column, Value: value}
+	case opLike:
+		s := fmt.Sprint(value)
+		if !strings.Contains(s, "%") {
+			s = "%" + s + "%"
+		}
+		return clause.Like{Column: column, Value: s}
+	case opNull:
+		if v.Kind() != reflect.Bool {
+			p.err = fmt.Errorf("null condition on <%s> must be bool", field.column)
+			return nil
+		}
+		if v.Bool() {
+			return clause.Eq{Column: column, Value: nil}
+		}
+		return clause.Neq{Column: column, Value: nil}
+	}
+	return nil
+}
+
+func listValues(v reflect.Value) []any {
+	values := make([]any, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		values = append(values, v.Index(i).Interface())
+	}
+	return values
+}
+
+// parseOrder 解析排序, 如 "-created_at", "created_at desc", 列不在允许的列表中时报错, 避免注入
+func (p *conditionParser) parseOrder(field conditionField, v reflect.Value) {
+	var items []string
+	switch v.Kind() {
+	case reflect.String:
+		items = strings.Split(v.String(), ",")
+	case reflect.Slice:
+		if s, ok := v.Interface().([]string); ok {
+			items = s
+		}
+	}
+	if items == nil {
+		p.err = fmt.Errorf("order field must be string or []string, got %s", v.Type())
+		return
+	}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		column, direction, _ := strings.Cut(item, " ")
+		desc := strings.EqualFold(strings.TrimSpace(direction), "desc")
+		if direction != "" && !desc && !strings.EqualFold(strings.TrimSpace(direction), "asc") {
+			p.err = fmt.Errorf("invalid order direction <%s>", direction)
+			return
+		}
+		if strings.HasPrefix(column, "-") {
+			column, desc = column[1:], true
+		}
+		if !slices.Contains(field.order, column) {
+			p.err = fmt.Errorf("order by <%s> is not allowed", column)
+			return
+		}
+		p.orders = append(p.orders, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+}
@@ -0,0 +1,139 @@
+package gormx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type order struct {
+	ID       uint64
+	Status   int
+	Name     string
+	Amount   int
+	Remark   *string
+	Customer string
+}
+
+type orderCondition struct {
+	Pagination
+	Status       []int             `where:"status"`
+	MinAmount    int               `where:"amount,gte"`
+	MaxAmount    *int              `where:"amount,lt"`
+	Name         string            `where:"name,like"`
+	NoRemark     *bool             `where:"remark,null"`
+	Excluded     []int             `where:"id,notin"`
+	Ignored      string            `where:"-"`
+	Sort         string            `order:"amount,id"`
+	Keyword      *keywordCondition `where:",or"`
+	CustomerName string            `where:""`
+}
+
+type keywordCondition struct {
+	Name     string          `where:"name,like"`
+	Customer string          `where:"customer"`
+	Large    *largeCondition `where:",and"`
+}
+
+type largeCondition struct {
+	Status int `where:"status"`
+	Amount int `where:"amount,gt"`
+}
+
+func TestParseCondition(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.AutoMigrate(&order{}))
+
+	sql := func(condition orderCondition) (string, error) {
+		var orders []order
+		stmt := ParseCondition(db.Session(&gorm.Session{DryRun: true}), condition).Find(&orders)
+		return stmt.Statement.SQL.String(), stmt.Error
+	}
+
+	t.Run("zero values skipped", func(t *testing.T) {
+		query, err := sql(orderCondition{Ignored: "x", Status: []int{}})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM `orders`", query)
+	})
+
+	t.Run("operators", func(t *testing.T) {
+		maxAmount, noRemark := 0, true
+		query, err := sql(orderCondition{
+			Status:       []int{1, 2},
+			MinAmount:    10,
+			MaxAmount:    &maxAmount,
+			Name:         "phone",
+			NoRemark:     &noRemark,
+			Excluded:     []int{7, 8},
+			CustomerName: "bob",
+			Sort:         "-amount, id",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM `orders` WHERE `status` IN (?,?) AND `amount` >= ? AND `amount` < ? AND `name` LIKE ? AND `remark` IS NULL AND `id` NOT IN (?,?) AND `customer_name` = ? ORDER BY `amount` DESC,`id`", query)
+	})
+
+	t.Run("nested groups", func(t *testing.T) {
+		query, err := sql(orderCondition{
+			MinAmount: 1,
+			Keyword: &keywordCondition{
+				Name:     "phone",
+				Customer: "alice",
+				Large:    &largeCondition{Status: 3, Amount: 100},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM `orders` WHERE `amount` >= ? AND (`name` LIKE ? OR `customer` = ? OR (`status` = ? AND `amount` > ?))", query)
+	})
+
+	t.Run("single member group", func(t *testing.T) {
+		query, err := sql(orderCondition{
+			Status:  []int{1, 2},
+			Keyword: &keywordCondition{Name: "phone"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM `orders` WHERE `status` IN (?,?) AND `name` LIKE ?", query)
+
+		query, err = sql(orderCondition{
+			MinAmount: 1,
+			Keyword:   &keywordCondition{Large: &largeCondition{Status: 3}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM `orders` WHERE `amount` >= ? AND `status` = ?", query)
+	})
+
+	t.Run("order not allowed", func(t *testing.T) {
+		_, err := sql(orderCondition{Sort: "name"})
+		require.EqualError(t, err, "order by <name> is not allowed")
+		_, err = sql(orderCondition{Sort: "id; drop table orders"})
+		require.Error(t, err)
+	})
+
+	t.Run("default parser of repository", func(t *testing.T) {
+		remark := "gift"
+		require.NoError(t, db.Create([]*order{
+			{ID: 1, Status: 1, Name: "phone", Amount: 100, Customer: "alice"},
+			{ID: 2, Status: 2, Name: "laptop", Amount: 300, Customer: "bob", Remark: &remark},
+			{ID: 3, Status: 3, Name: "phone case", Amount: 20, Customer: "carol"},
+		}).Error)
+		repo := NewHolderRepository[order, order, orderCondition](NewHolder(db), nil)
+		ctx := context.Background()
+
+		list, err := repo.List(ctx, orderCondition{Name: "phone", Sort: "-amount"})
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.EqualValues(t, 1, list[0].ID)
+
+		noRemark := false
+		count, err := repo.Count(ctx, orderCondition{NoRemark: &noRemark})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, count)
+
+		total, list, err := repo.Pagination(ctx, orderCondition{Pagination: NewPagination(1, 1), Status: []int{1, 3}, Sort: "id"})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, total)
+		require.Len(t, list, 1)
+		require.EqualValues(t, 1, list[0].ID)
+	})
+}
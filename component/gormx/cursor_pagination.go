@@ -0,0 +1,240 @@
+package gormx
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorKey 游标分页的排序列
+type CursorKey struct {
+	Column string
+	Desc   bool
+}
+
+func Asc(column string) CursorKey {
+	return CursorKey{Column: column}
+}
+
+func Desc(column string) CursorKey {
+	return CursorKey{Column: column, Desc: true}
+}
+
+func (k CursorKey) String() string {
+	if k.Desc {
+		return "-" + k.Column
+	}
+	return k.Column
+}
+
+// CursorPagination 游标分页, 按 Keys 排序后从 Cursor 的位置取 Size 条, 不受偏移量和并发插入的影响.
+// Keys 的组合需要唯一才能在排序值相同时保持稳定, HolderRepository.CursorPagination 会自动追加主键
+type CursorPagination struct {
+	Keys   []CursorKey
+	Size   int
+	Cursor string // 上一次返回的 CursorPage.Next 或 CursorPage.Prev, 为空时从第一页开始
+}
+
+func NewCursorPagination(size int, cursor string, keys ...CursorKey) CursorPagination {
+	return CursorPagination{
+		Keys:   keys,
+		Size:   size,
+		Cursor: cursor,
+	}
+}
+
+func (p CursorPagination) Validate() bool {
+	return p.Size > 0 && len(p.Keys) > 0
+}
+
+func (p CursorPagination) cursorPagination() CursorPagination {
+	return p
+}
+
+// SetQuery 设置排序, 游标位置的条件和 Size+1 的 limit, 多取的一条用于判断是否还有下一页
+func (p CursorPagination) SetQuery(query *gorm.DB) *gorm.DB {
+	c, err := p.decode()
+	if err != nil {
+		query.AddError(err)
+		return query
+	}
+	backward := c != nil && c.Backward
+
+	if c != nil {
+		ors := make([]clause.Expression, 0, len(p.Keys))
+		for i, key := range p.Keys {
+			ands := make([]clause.Expression, 0, i+1)
+			for j := 0; j < i; j++ {
+				ands = append(ands, clause.Eq{Column: p.Keys[j].Column, Value: c.Values[j]})
+			}
+			// 向前翻页时比较方向与排序方向相反
+			if key.Desc != backward {
+				ands = append(ands, clause.Lt{Column: key.Column, Value: c.Values[i]})
+			} else {
+				ands = append(ands, clause.Gt{Column: key.Column, Value: c.Values[i]})
+			}
+			ors = append(ors, clause.And(ands...))
+		}
+		query = query.Where(clause.Or(ors...))
+	}
+
+	for _, key := range p.Keys {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: key.Column}, Desc: key.Desc != backward})
+	}
+	return query.Limit(p.Size + 1)
+}
+
+// CursorPage 游标分页的结果, 没有下一页或上一页时对应的游标为空
+type CursorPage struct {
+	Next string
+	Prev string
+}
+
+type cursorPaginationCondition interface {
+	PaginationCondition
+	cursorPagination() CursorPagination
+}
+
+// cursor 游标的内容, 编码为 base64 后对调用方不透明
+type cursor struct {
+	Keys     []string      `json:"k"`
+	Backward bool          `json:"b,omitempty"`
+	Values   []any         `json:"-"`
+	Raw      []cursorValue `json:"v"`
+}
+
+// cursorValue 记录值的类型, 解码后与原来的类型一致, 避免大整数丢失精度或时间按字符串比较
+type cursorValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+func (p CursorPagination) keys() []string {
+	keys := make([]string, 0, len(p.Keys))
+	for _, key := range p.Keys {
+		keys = append(keys, key.String())
+	}
+	return keys
+}
+
+func (p CursorPagination) encode(values []any, backward bool) (token string, err error) {
+	c := cursor{Keys: p.keys(), Backward: backward, Raw: make([]cursorValue, 0, len(values))}
+	for i, value := range values {
+		var v cursorValue
+		v.Type, value, err = normalizeCursorValue(value)
+		if err != nil {
+			return "", fmt.Errorf("cursor key <%s>: %w", p.Keys[i].Column, err)
+		}
+		v.Value, err = json.Marshal(value)
+		if err != nil {
+			return
+		}
+		c.Raw = append(c.Raw, v)
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// normalizeCursorValue 按底层类型转换为基础类型, 支持自定义类型, 指针和 driver.Valuer (如 sql.NullInt64),
+// 值为 NULL 时无法比较位置, 返回错误
+func normalizeCursorValue(value any) (typ string, normalized any, err error) {
+	rv := reflect.ValueOf(value)
+	for rv.IsValid() && rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "", nil, errors.New("null value is not supported")
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "", nil, errors.New("null value is not supported")
+	}
+	if rv.Type().ConvertibleTo(timeType) {
+		return "time", rv.Convert(timeType).Interface(), nil
+	}
+	if valuer, ok := rv.Interface().(driver.Valuer); ok {
+		value, err = valuer.Value()
+		if err != nil {
+			return
+		}
+		return normalizeCursorValue(value)
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int", rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint", rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return "float", rv.Float(), nil
+	case reflect.String:
+		return "string", rv.String(), nil
+	case reflect.Bool:
+		return "bool", rv.Bool(), nil
+	default:
+		return "", nil, fmt.Errorf("unsupported cursor value type %T", value)
+	}
+}
+
+// decode 解析游标, 游标为空时返回nil
+func (p CursorPagination) decode() (c *cursor, err error) {
+	if p.Cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	c = &cursor{}
+	err = json.Unmarshal(b, c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if !slices.Equal(c.Keys, p.keys()) || len(c.Raw) != len(c.Keys) {
+		return nil, fmt.Errorf("%w: cursor keys <%s> do not match <%s>", ErrInvalidCursor, strings.Join(c.Keys, ","), strings.Join(p.keys(), ","))
+	}
+	c.Values = make([]any, 0, len(c.Raw))
+	for _, raw := range c.Raw {
+		var value any
+		switch raw.Type {
+		case "time":
+			value, err = unmarshalCursorValue[time.Time](raw.Value)
+		case "int":
+			value, err = unmarshalCursorValue[int64](raw.Value)
+		case "uint":
+			value, err = unmarshalCursorValue[uint64](raw.Value)
+		case "float":
+			value, err = unmarshalCursorValue[float64](raw.Value)
+		case "string":
+			value, err = unmarshalCursorValue[string](raw.Value)
+		case "bool":
+			value, err = unmarshalCursorValue[bool](raw.Value)
+		default:
+			err = fmt.Errorf("unknown value type %s", raw.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+		}
+		c.Values = append(c.Values, value)
+	}
+	return
+}
+
+func unmarshalCursorValue[T any](raw json.RawMessage) (value T, err error) {
+	err = json.Unmarshal(raw, &value)
+	return
+}
@@ -0,0 +1,160 @@
+package gormx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type post struct {
+	ID    uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Score int
+}
+
+type level int
+
+type task struct {
+	ID    uint64 `gorm:"primaryKey"`
+	Level level
+	DueAt *time.Time
+}
+
+type taskCondition struct {
+	CursorPagination
+}
+
+type postCondition struct {
+	CursorPagination
+	MinScore int
+}
+
+func parsePostCondition(query *gorm.DB, condition postCondition) *gorm.DB {
+	if condition.MinScore != 0 {
+		query = query.Where("score >= ?", condition.MinScore)
+	}
+	return query
+}
+
+func TestCursorPagination(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.AutoMigrate(&post{}))
+
+	// 超过 2^53 的 id 验证游标不丢失精度, 分数有重复验证排序稳定
+	const base = uint64(1) << 60
+	var posts []*post
+	for i, score := range []int{5, 3, 5, 1, 3, 5, 2} {
+		posts = append(posts, &post{ID: base + uint64(i), Score: score})
+	}
+	require.NoError(t, db.Create(posts).Error)
+	// 按 score desc, id desc 排序
+	expected := []uint64{base + 5, base + 2, base, base + 4, base + 1, base + 6, base + 3}
+
+	repo := NewHolderRepository[post, post, postCondition](NewHolder(db), parsePostCondition)
+	ctx := context.Background()
+	ids := func(list []*post) (result []uint64) {
+		for _, item := range list {
+			result = append(result, item.ID)
+		}
+		return
+	}
+
+	t.Run("forward and backward", func(t *testing.T) {
+		condition := postCondition{CursorPagination: NewCursorPagination(3, "", Desc("score"))}
+		list, page, err := repo.CursorPagination(ctx, condition)
+		require.NoError(t, err)
+		require.Equal(t, expected[:3], ids(list))
+		require.Empty(t, page.Prev)
+		require.NotEmpty(t, page.Next)
+
+		condition.Cursor = page.Next
+		list, page, err = repo.CursorPagination(ctx, condition)
+		require.NoError(t, err)
+		require.Equal(t, expected[3:6], ids(list))
+		second := page
+
+		condition.Cursor = page.Next
+		list, page, err = repo.CursorPagination(ctx, condition)
+		require.NoError(t, err)
+		require.Equal(t, expected[6:], ids(list))
+		require.Empty(t, page.Next)
+		require.NotEmpty(t, page.Prev)
+
+		condition.Cursor = page.Prev
+		list, page, err = repo.CursorPagination(ctx, condition)
+		require.NoError(t, err)
+		require.Equal(t, expected[3:6], ids(list))
+		require.Equal(t, second, page)
+
+		condition.Cursor = page.Prev
+		list, page, err = repo.CursorPagination(ctx, condition)
+		require.NoError(t, err)
+		require.Equal(t, expected[:3], ids(list))
+		require.Empty(t, page.Prev)
+		require.NotEmpty(t, page.Next)
+	})
+
+	t.Run("with condition", func(t *testing.T) {
+		condition := postCondition{CursorPagination: NewCursorPagination(2, "", Desc("score")), MinScore: 3}
+		var all []uint64
+		for {
+			list, page, err := repo.CursorPagination(ctx, condition)
+			require.NoError(t, err)
+			all = append(all, ids(list)...)
+			if page.Next == "" {
+				break
+			}
+			condition.Cursor = page.Next
+		}
+		require.Equal(t, expected[:5], all)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, _, err := repo.CursorPagination(ctx, postCondition{CursorPagination: NewCursorPagination(2, "not a cursor", Desc("score"))})
+		require.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, page, err := repo.CursorPagination(ctx, postCondition{CursorPagination: NewCursorPagination(2, "", Desc("score"))})
+		require.NoError(t, err)
+		_, _, err = repo.CursorPagination(ctx, postCondition{CursorPagination: NewCursorPagination(2, page.Next, Asc("score"))})
+		require.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, _, err = repo.CursorPagination(ctx, postCondition{CursorPagination: NewCursorPagination(2, "", Asc("missing"))})
+		require.EqualError(t, err, "cursor key <missing> is not a field of post")
+	})
+
+	t.Run("named and pointer keys", func(t *testing.T) {
+		require.NoError(t, db.AutoMigrate(&task{}))
+		now := time.Now().UTC().Truncate(time.Second)
+		due := func(d time.Duration) *time.Time {
+			at := now.Add(d)
+			return &at
+		}
+		require.NoError(t, db.Create([]*task{
+			{ID: 1, Level: 2, DueAt: due(time.Hour)},
+			{ID: 2, Level: 1, DueAt: due(time.Minute)},
+			{ID: 3, Level: 2, DueAt: due(time.Minute)},
+		}).Error)
+		tasks := NewHolderRepository[task, task, taskCondition](NewHolder(db), func(query *gorm.DB, condition taskCondition) *gorm.DB {
+			return query
+		})
+
+		condition := taskCondition{CursorPagination: NewCursorPagination(2, "", Desc("level"), Asc("due_at"))}
+		list, page, err := tasks.CursorPagination(ctx, condition)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.Equal(t, []uint64{3, 1}, []uint64{list[0].ID, list[1].ID})
+
+		condition.Cursor = page.Next
+		list, _, err = tasks.CursorPagination(ctx, condition)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.EqualValues(t, 2, list[0].ID)
+
+		// 排序列为 NULL 时无法确定位置
+		require.NoError(t, db.Create(&task{ID: 4, Level: 3}).Error)
+		_, _, err = tasks.CursorPagination(ctx, taskCondition{CursorPagination: NewCursorPagination(1, "", Desc("level"), Asc("due_at"))})
+		require.EqualError(t, err, "cursor key <due_at>: null value is not supported")
+	})
+}
@@ -0,0 +1,137 @@
+package gormx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB 记录执行的语句, 不依赖真实数据库
+type fakeDB struct {
+	lock   sync.Mutex
+	stmts  []string
+	lastID int64
+	// rows 为 SELECT 语句返回的结果, 为空时返回空结果
+	rows func(query string) (columns []string, values [][]driver.Value)
+}
+
+func newFakeDB(t *testing.T) (*fakeDB, *gorm.DB) {
+	f := &fakeDB{}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(f),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	return f, db
+}
+
+func (f *fakeDB) record(stmt string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.stmts = append(f.stmts, stmt)
+}
+
+// statements 返回并清空已执行的语句
+func (f *fakeDB) statements() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	stmts := f.stmts
+	f.stmts = nil
+	return stmts
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.record("COMMIT")
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.record("ROLLBACK")
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query)
+	c.db.lock.Lock()
+	defer c.db.lock.Unlock()
+	if strings.HasPrefix(query, "INSERT") {
+		c.db.lastID++
+		return fakeResult(c.db.lastID), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) {
+	return int64(r), nil
+}
+
+func (r fakeResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query)
+	rows := &fakeRows{}
+	if c.db.rows != nil {
+		rows.columns, rows.values = c.db.rows(query)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,18 @@
+package gormx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 打开一个独立的 sqlite 内存数据库
+func newTestDB(t *testing.T) *gorm.DB {
+	d, err := Dialector("sqlite://file::memory:?cache=private")
+	require.NoError(t, err)
+	db, err := gorm.Open(d, &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	return db
+}
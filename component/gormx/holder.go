@@ -16,16 +16,28 @@ type Holder interface {
 var _ Holder = (*DB)(nil)
 var _ database.TxManager = (*DB)(nil)
 
-func NewHolder(db *gorm.DB) *DB {
-	return &DB{
+func NewHolder(db *gorm.DB, opts ...func(*DB)) *DB {
+	h := &DB{
 		DB:  db,
 		ctx: context.Background(),
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// WithReplicas 只读查询路由到副本
+func WithReplicas(replicas *Replicas) func(*DB) {
+	return func(h *DB) {
+		h.replicas = replicas
+	}
 }
 
 type DB struct {
 	ctx context.Context
 	*gorm.DB
+	replicas *Replicas
 }
 
 func (h *DB) GetDB() *gorm.DB {
@@ -36,16 +48,54 @@ func (h *DB) WithContext(ctx context.Context) *DB {
 	// 从上下文中获取事务
 	tx, ok := ctx.Value(database.TxKey).(*gorm.DB)
 	if ok {
-		return &DB{ctx: ctx, DB: tx.WithContext(ctx)}
+		return &DB{ctx: ctx, DB: tx.WithContext(ctx), replicas: h.replicas}
+	}
+	return &DB{ctx: ctx, DB: h.DB.WithContext(ctx), replicas: h.replicas}
+}
+
+// Reader 用于只读查询, 路由到健康的副本; 上下文中有事务, 标记了 UsePrimary, 或没有健康的副本时使用主库
+func (h *DB) Reader(ctx context.Context) *DB {
+	if _, ok := ctx.Value(database.TxKey).(*gorm.DB); ok || inTransaction(h.DB) {
+		return h.WithContext(ctx)
+	}
+	if primary, _ := ctx.Value(usePrimaryKey{}).(bool); primary {
+		return h.WithContext(ctx)
+	}
+	replica := h.replicas.Pick()
+	if replica == nil {
+		return h.WithContext(ctx)
 	}
-	return &DB{ctx: ctx, DB: h.DB.WithContext(ctx)}
+	return &DB{ctx: ctx, DB: replica.WithContext(ctx), replicas: h.replicas}
 }
 
+// Transaction 在事务中执行 f, 提交后执行通过 AfterCommit 注册的函数
 func (h *DB) Transaction(f func(ctx context.Context) error, opts ...*sql.TxOptions) error {
-	return h.DB.Transaction(func(tx *gorm.DB) error {
+	nested := inTransaction(h.DB)
+	var pool gorm.ConnPool
+	var mark int
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		pool = tx.Statement.ConnPool
+		if nested {
+			mark = countTxHooks(pool)
+		} else {
+			beginTxHooks(pool)
+		}
 		ctx := context.WithValue(h.ctx, database.TxKey, tx)
 		return f(ctx)
 	}, opts...)
+	switch {
+	case pool == nil:
+	case nested:
+		// 嵌套事务由外层事务提交
+		if err != nil {
+			truncateTxHooks(pool, mark)
+		}
+	case err != nil:
+		discardTxHooks(pool)
+	default:
+		runTxHooks(pool)
+	}
+	return err
 }
 
 func (h *DB) Begin(opts ...*sql.TxOptions) (context.Context, error) {
@@ -54,6 +104,7 @@ func (h *DB) Begin(opts ...*sql.TxOptions) (context.Context, error) {
 	if err != nil {
 		return nil, err
 	}
+	beginTxHooks(tx.Statement.ConnPool)
 	ctx := context.WithValue(h.ctx, database.TxKey, tx)
 	return ctx, tx.Error
 }
@@ -67,9 +118,19 @@ func (h *DB) TransactionCtx(ctx context.Context, f func(ctx context.Context) err
 }
 
 func (h *DB) Commit(ctx context.Context) error {
-	return h.WithContext(ctx).DB.Commit().Error
+	db := h.WithContext(ctx).DB
+	pool := db.Statement.ConnPool
+	err := db.Commit().Error
+	if err != nil {
+		discardTxHooks(pool)
+		return err
+	}
+	runTxHooks(pool)
+	return nil
 }
 
 func (h *DB) Rollback(ctx context.Context) error {
-	return h.WithContext(ctx).DB.Rollback().Error
+	db := h.WithContext(ctx).DB
+	discardTxHooks(db.Statement.ConnPool)
+	return db.Rollback().Error
 }
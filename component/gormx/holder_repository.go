@@ -3,14 +3,35 @@ package gormx
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"slices"
 
 	"github.com/goslacker/slacker/core/tool"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 var ErrNotFound = errors.New("not found")
 
+// ErrConcurrentUpdate 乐观锁冲突, 记录已被其他请求修改或删除
+var ErrConcurrentUpdate = errors.New("concurrent update")
+
+// ConcurrentUpdateError 乐观锁冲突的详细信息, errors.Is(err, ErrConcurrentUpdate) 为 true
+type ConcurrentUpdateError struct {
+	Table   string
+	Version int64
+}
+
+func (e *ConcurrentUpdateError) Error() string {
+	return fmt.Sprintf("concurrent update: %s version %d is outdated", e.Table, e.Version)
+}
+
+func (e *ConcurrentUpdateError) Is(target error) bool {
+	return target == ErrConcurrentUpdate
+}
+
 type Pagination struct {
 	Page int
 	Size int
@@ -63,7 +84,11 @@ type HolderRepository[PO any, Entity any, Condition any] struct {
 	parseCondition func(query *gorm.DB, condition Condition) *gorm.DB
 }
 
+// NewHolderRepository 创建仓储, parseCondition 为 nil 时按 Condition 的结构体标签解析条件, 见 ParseCondition
 func NewHolderRepository[PO any, Entity any, Condition any](db *DB, parseCondition func(query *gorm.DB, condition Condition) *gorm.DB) *HolderRepository[PO, Entity, Condition] {
+	if parseCondition == nil {
+		parseCondition = ParseCondition[Condition]
+	}
 	return &HolderRepository[PO, Entity, Condition]{
 		db:             db,
 		parseCondition: parseCondition,
@@ -74,21 +99,76 @@ func (h *HolderRepository[PO, Entity, Condition]) DB(ctx context.Context) *gorm.
 	return h.db.WithContext(ctx).GetDB()
 }
 
+// BuildQuery 按条件构建查询, 上下文标记了 WithTrashed 时包含已软删除的记录
 func (h *HolderRepository[PO, Entity, Condition]) BuildQuery(ctx context.Context, condition Condition) *gorm.DB {
 	query := h.db.WithContext(ctx).GetDB()
+	if withTrashed(ctx) {
+		query = query.Unscoped()
+	}
 	query = h.parseCondition(query, condition)
 	return query
 }
 
+// BuildReadQuery 与 BuildQuery 相同, 但只读查询会路由到副本, 见 DB.Reader.
+// 只有 Find 和 List 使用, 其他查询使用主库, 如 Exists 常用于写入前的检查, 读到延迟的副本会导致重复写入
+func (h *HolderRepository[PO, Entity, Condition]) BuildReadQuery(ctx context.Context, condition Condition) *gorm.DB {
+	query := h.db.Reader(ctx).GetDB()
+	if withTrashed(ctx) {
+		query = query.Unscoped()
+	}
+	query = h.parseCondition(query, condition)
+	return query
+}
+
+// Save 创建或更新, 嵌入 Version 时版本为 0 的创建, 其他的按版本更新, 版本不一致时返回 ErrConcurrentUpdate
 func (h *HolderRepository[PO, Entity, Condition]) Save(ctx context.Context, entities ...*Entity) error {
 	if len(entities) == 0 {
 		return nil
 	}
+	if _, ok := any(new(PO)).(versioned); ok {
+		return tool.SimpleMapFuncBack(entities, func(dest []*PO) (err error) {
+			return h.db.TransactionCtx(ctx, func(ctx context.Context) (err error) {
+				for _, model := range dest {
+					err = h.saveVersioned(ctx, model)
+					if err != nil {
+						return
+					}
+				}
+				return
+			})
+		})
+	}
 	return tool.SimpleMapFuncBack(entities, func(dest []*PO) (err error) {
 		return h.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&dest).Error
 	})
 }
 
+func (h *HolderRepository[PO, Entity, Condition]) saveVersioned(ctx context.Context, model *PO) (err error) {
+	version := any(model).(versioned).versionField()
+	if *version == 0 {
+		*version = 1
+		return h.db.WithContext(ctx).Create(model).Error
+	}
+	return h.updateVersioned(ctx, model, func(query *gorm.DB) *gorm.DB {
+		return query.Select("*")
+	})
+}
+
+// updateVersioned 以当前版本为条件更新并递增版本, 没有更新到记录时恢复版本并返回 ConcurrentUpdateError
+func (h *HolderRepository[PO, Entity, Condition]) updateVersioned(ctx context.Context, model *PO, scope func(query *gorm.DB) *gorm.DB) (err error) {
+	version := any(model).(versioned).versionField()
+	current := *version
+	*version = current + 1
+	result := scope(h.db.WithContext(ctx).GetDB().Model(model).Where("version = ?", current)).Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = &ConcurrentUpdateError{Table: result.Statement.Table, Version: current}
+	}
+	if result.Error != nil {
+		*version = current
+	}
+	return result.Error
+}
+
 func (h *HolderRepository[PO, Entity, Condition]) SaveInBatches(ctx context.Context, batchSize int, entities ...*Entity) error {
 	h = &HolderRepository[PO, Entity, Condition]{
 		db:             NewHolder(h.db.GetDB().Session(&gorm.Session{CreateBatchSize: batchSize})),
@@ -97,13 +177,29 @@ func (h *HolderRepository[PO, Entity, Condition]) SaveInBatches(ctx context.Cont
 	return h.Save(ctx, entities...)
 }
 
+// Delete 按条件删除, 嵌入 SoftDelete 时为软删除, 上下文标记了 WithTrashed 时永久删除
 func (h *HolderRepository[PO, Entity, Condition]) Delete(ctx context.Context, condition Condition) error {
 	query := h.BuildQuery(ctx, condition)
 	return query.Delete(new(PO)).Error
 }
 
+// Restore 恢复按条件匹配的已软删除的记录, 模型需要嵌入 SoftDelete
+func (h *HolderRepository[PO, Entity, Condition]) Restore(ctx context.Context, condition Condition) (err error) {
+	query := h.BuildQuery(ctx, condition).Unscoped().Model(new(PO))
+	err = query.Statement.Parse(new(PO))
+	if err != nil {
+		return
+	}
+	field := deletedAtField(query.Statement.Schema)
+	if field == nil {
+		return fmt.Errorf("%s does not support soft delete", query.Statement.Schema.Name)
+	}
+	return query.Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: 0}).
+		Update(field.DBName, DeletedAt(0)).Error
+}
+
 func (h *HolderRepository[PO, Entity, Condition]) Find(ctx context.Context, condition Condition) (entity *Entity, err error) {
-	query := h.BuildQuery(ctx, condition)
+	query := h.BuildReadQuery(ctx, condition)
 	var model PO
 	err = query.First(&model).Error
 	if err != nil {
@@ -117,7 +213,7 @@ func (h *HolderRepository[PO, Entity, Condition]) Find(ctx context.Context, cond
 }
 
 func (h *HolderRepository[PO, Entity, Condition]) List(ctx context.Context, condition Condition) (entities []*Entity, err error) {
-	query := h.BuildQuery(ctx, condition)
+	query := h.BuildReadQuery(ctx, condition)
 	var models []*PO
 	err = query.Find(&models).Error
 	if err != nil {
@@ -159,9 +255,96 @@ func (h *HolderRepository[PO, Entity, Condition]) Pagination(ctx context.Context
 	return
 }
 
+// CursorPagination 游标分页查询, condition 需要嵌入 CursorPagination, 自动追加主键作为最后的排序列, 返回下一页和上一页的游标
+func (h *HolderRepository[PO, Entity, Condition]) CursorPagination(ctx context.Context, condition Condition) (list []*Entity, page CursorPage, err error) {
+	cp, ok := any(condition).(cursorPaginationCondition)
+	if !ok {
+		err = errors.New("condition must embed CursorPagination")
+		return
+	}
+	pagination := cp.cursorPagination()
+	query := h.BuildQuery(ctx, condition).Model(new(PO))
+	err = query.Statement.Parse(new(PO))
+	if err != nil {
+		return
+	}
+	s := query.Statement.Schema
+
+	pagination.Keys = slices.Clone(pagination.Keys)
+	desc := len(pagination.Keys) > 0 && pagination.Keys[len(pagination.Keys)-1].Desc
+	for _, name := range s.PrimaryFieldDBNames {
+		if !slices.ContainsFunc(pagination.Keys, func(key CursorKey) bool { return key.Column == name }) {
+			pagination.Keys = append(pagination.Keys, CursorKey{Column: name, Desc: desc})
+		}
+	}
+	if !pagination.Validate() {
+		pagination.Size = 1000 // 分页参数不合法时，默认分页参数
+	}
+	fields := make([]*schema.Field, 0, len(pagination.Keys))
+	for _, key := range pagination.Keys {
+		field := s.LookUpField(key.Column)
+		if field == nil {
+			err = fmt.Errorf("cursor key <%s> is not a field of %s", key.Column, s.Name)
+			return
+		}
+		fields = append(fields, field)
+	}
+	c, err := pagination.decode()
+	if err != nil {
+		return
+	}
+	backward := c != nil && c.Backward
+
+	var models []*PO
+	err = pagination.SetQuery(query).Find(&models).Error
+	if err != nil {
+		return
+	}
+	more := len(models) > pagination.Size
+	if more {
+		models = models[:pagination.Size]
+	}
+	if backward {
+		slices.Reverse(models)
+	}
+
+	if len(models) > 0 {
+		token := func(model *PO, backward bool) (string, error) {
+			values := make([]any, 0, len(fields))
+			for _, field := range fields {
+				value, _ := field.ValueOf(ctx, reflect.ValueOf(model).Elem())
+				values = append(values, value)
+			}
+			return pagination.encode(values, backward)
+		}
+		// 向后翻页时多取的一条说明还有下一页, 从游标位置翻页时另一个方向一定还有数据
+		if more && !backward || c != nil && backward {
+			page.Next, err = token(models[len(models)-1], false)
+			if err != nil {
+				return
+			}
+		}
+		if more && backward || c != nil && !backward {
+			page.Prev, err = token(models[0], true)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	err = tool.SimpleMap(&list, models)
+	return
+}
+
+// Update 按主键更新非零值字段, 嵌入 Version 时按版本更新, 版本不一致时返回 ErrConcurrentUpdate
 func (h *HolderRepository[PO, Entity, Condition]) Update(ctx context.Context, entity *Entity) error {
 	return tool.SimpleMapFuncBack(entity, func(dest *PO) (err error) {
-		return h.db.GetDB().Updates(dest).Error
+		if _, ok := any(dest).(versioned); ok {
+			return h.updateVersioned(ctx, dest, func(query *gorm.DB) *gorm.DB {
+				return query
+			})
+		}
+		return h.db.WithContext(ctx).Updates(dest).Error
 	})
 }
 
@@ -178,7 +361,7 @@ func (h *HolderRepository[PO, Entity, Condition]) Updates(ctx context.Context, c
 	switch x := entOrMap.(type) {
 	case *Entity:
 		err = tool.SimpleMapFuncBack(x, func(dest *PO) (err error) {
-			return query.Updates(x).Error
+			return query.Updates(dest).Error
 		})
 	case map[string]any:
 		err = query.Model(new(PO)).Updates(x).Error
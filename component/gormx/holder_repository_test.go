@@ -0,0 +1,116 @@
+package gormx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/goslacker/slacker/core/jwtx"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type document struct {
+	ID    uint64
+	Title string
+	Version
+	SoftDelete
+	AuditField
+}
+
+type documentCondition struct {
+	ID    uint64
+	Title string
+}
+
+func parseDocumentCondition(query *gorm.DB, condition documentCondition) *gorm.DB {
+	if condition.ID != 0 {
+		query = query.Where("id = ?", condition.ID)
+	}
+	if condition.Title != "" {
+		query = query.Where("title = ?", condition.Title)
+	}
+	return query
+}
+
+func TestHolderRepository_Features(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.Use(&auditPlugin{claim: jwtx.JwtSubject}))
+	require.NoError(t, db.AutoMigrate(&document{}))
+
+	repo := NewHolderRepository[document, document, documentCondition](NewHolder(db), parseDocumentCondition)
+	ctx := jwtx.NewContextWithClaims(context.Background(), jwt.MapClaims{jwtx.JwtSubject: "alice"})
+
+	t.Run("audit and version on save", func(t *testing.T) {
+		doc := &document{ID: 1, Title: "draft"}
+		require.NoError(t, repo.Save(ctx, doc))
+		require.EqualValues(t, 1, doc.Version.Version)
+		require.Equal(t, "alice", doc.CreatedBy)
+		require.Equal(t, "alice", doc.UpdatedBy)
+
+		bob := jwtx.NewContextWithClaims(context.Background(), jwt.MapClaims{jwtx.JwtSubject: "bob"})
+		doc.Title = "final"
+		require.NoError(t, repo.Save(bob, doc))
+		require.EqualValues(t, 2, doc.Version.Version)
+
+		found, err := repo.Find(ctx, documentCondition{ID: 1})
+		require.NoError(t, err)
+		require.Equal(t, "final", found.Title)
+		require.Equal(t, "alice", found.CreatedBy)
+		require.Equal(t, "bob", found.UpdatedBy)
+	})
+
+	t.Run("concurrent update", func(t *testing.T) {
+		first, err := repo.Find(ctx, documentCondition{ID: 1})
+		require.NoError(t, err)
+		second, err := repo.Find(ctx, documentCondition{ID: 1})
+		require.NoError(t, err)
+
+		first.Title = "first"
+		require.NoError(t, repo.Update(ctx, first))
+		second.Title = "second"
+		err = repo.Save(ctx, second)
+		require.ErrorIs(t, err, ErrConcurrentUpdate)
+		var conflict *ConcurrentUpdateError
+		require.ErrorAs(t, err, &conflict)
+		require.Equal(t, "documents", conflict.Table)
+		require.EqualValues(t, 2, conflict.Version)
+		require.EqualValues(t, 2, second.Version.Version)
+
+		require.ErrorIs(t, repo.Update(ctx, second), ErrConcurrentUpdate)
+		found, err := repo.Find(ctx, documentCondition{ID: 1})
+		require.NoError(t, err)
+		require.Equal(t, "first", found.Title)
+	})
+
+	t.Run("soft delete", func(t *testing.T) {
+		require.NoError(t, repo.Save(ctx, &document{ID: 2, Title: "temp"}))
+		require.NoError(t, repo.Delete(ctx, documentCondition{ID: 2}))
+
+		_, err := repo.Find(ctx, documentCondition{ID: 2})
+		require.ErrorIs(t, err, ErrNotFound)
+		list, err := repo.List(ctx, documentCondition{})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+
+		trashed, err := repo.Find(WithTrashed(ctx), documentCondition{ID: 2})
+		require.NoError(t, err)
+		require.NotZero(t, trashed.DeletedAt)
+
+		require.NoError(t, repo.Restore(ctx, documentCondition{ID: 2}))
+		restored, err := repo.Find(ctx, documentCondition{ID: 2})
+		require.NoError(t, err)
+		require.Zero(t, restored.DeletedAt)
+
+		require.NoError(t, repo.Delete(WithTrashed(ctx), documentCondition{ID: 2}))
+		count, err := repo.Count(WithTrashed(ctx), documentCondition{})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, count)
+	})
+
+	t.Run("restore without soft delete", func(t *testing.T) {
+		require.NoError(t, db.AutoMigrate(&item{}))
+		items := NewHolderRepository[item, item, itemCondition](NewHolder(db), parseItemCondition)
+		require.EqualError(t, items.Restore(ctx, itemCondition{}), "item does not support soft delete")
+	})
+}
@@ -0,0 +1,72 @@
+package gormx
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+// loggerConfig gorm 日志配置, 对应 gormx.logger
+type loggerConfig struct {
+	SlowThreshold             int  `mapstructure:"slow_threshold" default:"200" validate:"gte=0"` // 慢查询阈值, 毫秒
+	LogLevel                  int  `mapstructure:"log_level" default:"4" validate:"min=1,max=4"`  // 1 Silent, 2 Error, 3 Warn, 4 Info
+	IgnoreRecordNotFoundError bool `mapstructure:"ignore_record_not_found_error" default:"true"`
+	Colorful                  bool `mapstructure:"colorful" default:"true"`
+	ParameterizedQueries      bool `mapstructure:"parameterized_queries" default:"false"`
+}
+
+func newLogger(conf loggerConfig) logger.Interface {
+	return logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+		SlowThreshold:             time.Duration(conf.SlowThreshold) * time.Millisecond,
+		LogLevel:                  logger.LogLevel(conf.LogLevel),
+		IgnoreRecordNotFoundError: conf.IgnoreRecordNotFoundError,
+		Colorful:                  conf.Colorful,
+		ParameterizedQueries:      conf.ParameterizedQueries,
+	})
+}
+
+var _ logger.Interface = (*reloadableLogger)(nil)
+
+func newReloadableLogger(l logger.Interface) *reloadableLogger {
+	r := &reloadableLogger{}
+	r.Store(l)
+	return r
+}
+
+// reloadableLogger 配置热更新时替换内部的 gorm logger, 已打开的 *gorm.DB 无需重建
+type reloadableLogger struct {
+	current atomic.Value
+}
+
+func (r *reloadableLogger) Store(l logger.Interface) {
+	r.current.Store(&l)
+}
+
+func (r *reloadableLogger) load() logger.Interface {
+	return *r.current.Load().(*logger.Interface)
+}
+
+// LogMode 返回当前 logger 指定级别的副本, 如 db.Debug(), 不随配置变化
+func (r *reloadableLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return r.load().LogMode(level)
+}
+
+func (r *reloadableLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	r.load().Info(ctx, msg, data...)
+}
+
+func (r *reloadableLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	r.load().Warn(ctx, msg, data...)
+}
+
+func (r *reloadableLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	r.load().Error(ctx, msg, data...)
+}
+
+func (r *reloadableLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	r.load().Trace(ctx, begin, fc, err)
+}
@@ -0,0 +1,176 @@
+package gormx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gorm.io/gorm"
+)
+
+const meterName = "github.com/goslacker/slacker/component/gormx"
+
+// metricsConfig 数据库指标配置, 对应 gormx.metrics
+type metricsConfig struct {
+	Enabled       bool          `mapstructure:"enabled" default:"true"`
+	SlowThreshold time.Duration `mapstructure:"slow_threshold" default:"200ms" validate:"gte=0"` // 超过阈值的查询计入慢查询, 0 不统计
+}
+
+var poolNameKey = attribute.Key("db.client.connection.pool.name")
+
+// dbMetrics 通过 otel meter 上报连接池状态, 查询耗时和慢查询次数, 未设置 MeterProvider 时不产生开销
+type dbMetrics struct {
+	slowThreshold time.Duration
+	duration      metric.Float64Histogram
+	slow          metric.Int64Counter
+
+	lock  sync.Mutex
+	pools map[string]*sql.DB
+}
+
+func newDBMetrics(meter metric.Meter, slowThreshold time.Duration) (m *dbMetrics, err error) {
+	m = &dbMetrics{
+		slowThreshold: slowThreshold,
+		pools:         make(map[string]*sql.DB),
+	}
+	m.duration, err = meter.Float64Histogram("db.client.operation.duration",
+		metric.WithDescription("Duration of database client operations."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10),
+	)
+	if err != nil {
+		return
+	}
+	m.slow, err = meter.Int64Counter("db.client.operation.slow",
+		metric.WithDescription("Number of database client operations slower than the threshold."),
+		metric.WithUnit("{operation}"),
+	)
+	if err != nil {
+		return
+	}
+
+	count, err := meter.Int64ObservableUpDownCounter("db.client.connection.count",
+		metric.WithDescription("Number of connections that are currently in state described by the state attribute."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return
+	}
+	maxConns, err := meter.Int64ObservableUpDownCounter("db.client.connection.max",
+		metric.WithDescription("The maximum number of open connections allowed."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return
+	}
+	waitCount, err := meter.Int64ObservableCounter("db.client.connection.wait_count",
+		metric.WithDescription("The total number of connections waited for."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return
+	}
+	waitTime, err := meter.Float64ObservableCounter("db.client.connection.wait_time",
+		metric.WithDescription("The total time blocked waiting for a new connection."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return
+	}
+	closed, err := meter.Int64ObservableCounter("db.client.connection.closed",
+		metric.WithDescription("The total number of connections closed by the reason attribute."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		for name, sqlDB := range m.pools {
+			stats := sqlDB.Stats()
+			pool := poolNameKey.String(name)
+			o.ObserveInt64(count, int64(stats.Idle), metric.WithAttributes(pool, attribute.String("db.client.connection.state", "idle")))
+			o.ObserveInt64(count, int64(stats.InUse), metric.WithAttributes(pool, attribute.String("db.client.connection.state", "used")))
+			o.ObserveInt64(maxConns, int64(stats.MaxOpenConnections), metric.WithAttributes(pool))
+			o.ObserveInt64(waitCount, stats.WaitCount, metric.WithAttributes(pool))
+			o.ObserveFloat64(waitTime, stats.WaitDuration.Seconds(), metric.WithAttributes(pool))
+			o.ObserveInt64(closed, stats.MaxIdleClosed, metric.WithAttributes(pool, attribute.String("reason", "max_idle")))
+			o.ObserveInt64(closed, stats.MaxIdleTimeClosed, metric.WithAttributes(pool, attribute.String("reason", "max_idle_time")))
+			o.ObserveInt64(closed, stats.MaxLifetimeClosed, metric.WithAttributes(pool, attribute.String("reason", "max_lifetime")))
+		}
+		return nil
+	}, count, maxConns, waitCount, waitTime, closed)
+	return
+}
+
+// watch 以 name 为连接池名称上报 db 的查询指标和连接池状态
+func (m *dbMetrics) watch(name string, db *gorm.DB) (err error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	m.lock.Lock()
+	m.pools[name] = sqlDB
+	m.lock.Unlock()
+	return db.Use(&metricsPlugin{metrics: m, pool: name})
+}
+
+const metricsStartKey = "gormx:metrics_start"
+
+// metricsPlugin 记录每次查询的耗时
+type metricsPlugin struct {
+	metrics *dbMetrics
+	pool    string
+}
+
+func (p *metricsPlugin) Name() string {
+	return "gormx:metrics"
+}
+
+func (p *metricsPlugin) Initialize(db *gorm.DB) (err error) {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(metricsStartKey, time.Now())
+	}
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("gormx:metrics_before_create", before),
+		cb.Create().After("*").Register("gormx:metrics_after_create", p.after("create")),
+		cb.Query().Before("*").Register("gormx:metrics_before_query", before),
+		cb.Query().After("*").Register("gormx:metrics_after_query", p.after("query")),
+		cb.Update().Before("*").Register("gormx:metrics_before_update", before),
+		cb.Update().After("*").Register("gormx:metrics_after_update", p.after("update")),
+		cb.Delete().Before("*").Register("gormx:metrics_before_delete", before),
+		cb.Delete().After("*").Register("gormx:metrics_after_delete", p.after("delete")),
+		cb.Row().Before("*").Register("gormx:metrics_before_row", before),
+		cb.Row().After("*").Register("gormx:metrics_after_row", p.after("row")),
+		cb.Raw().Before("*").Register("gormx:metrics_before_raw", before),
+		cb.Raw().After("*").Register("gormx:metrics_after_raw", p.after("raw")),
+	)
+}
+
+func (p *metricsPlugin) after(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(value.(time.Time))
+		ctx := tx.Statement.Context
+		attrs := metric.WithAttributes(
+			poolNameKey.String(p.pool),
+			attribute.String("db.system", tx.Dialector.Name()),
+			attribute.String("db.operation.name", operation),
+			attribute.Bool("error", tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound)),
+		)
+		p.metrics.duration.Record(ctx, elapsed.Seconds(), attrs)
+		if p.metrics.slowThreshold > 0 && elapsed > p.metrics.slowThreshold {
+			p.metrics.slow.Add(ctx, 1, attrs)
+		}
+	}
+}
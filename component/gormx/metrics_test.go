@@ -0,0 +1,87 @@
+package gormx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	metricSdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestDBMetrics(t *testing.T) {
+	reader := metricSdk.NewManualReader()
+	provider := metricSdk.NewMeterProvider(metricSdk.WithReader(reader))
+	defer provider.Shutdown(context.Background())
+
+	m, err := newDBMetrics(provider.Meter(meterName), time.Nanosecond)
+	require.NoError(t, err)
+
+	db := newTestDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	poolConfig{MaxOpenConns: 1, MaxIdleConns: 1}.apply(sqlDB)
+	require.NoError(t, m.watch("default", db))
+
+	require.NoError(t, db.AutoMigrate(&item{}))
+	require.NoError(t, db.Create(&item{Name: "a"}).Error)
+	var items []item
+	require.NoError(t, db.Find(&items).Error)
+	require.Error(t, db.Table("missing").Find(&items).Error)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, item := range sm.Metrics {
+			metrics[item.Name] = item
+		}
+	}
+
+	t.Run("operation duration", func(t *testing.T) {
+		points := metrics["db.client.operation.duration"].Data.(metricdata.Histogram[float64]).DataPoints
+		counts := make(map[string]uint64)
+		for _, point := range points {
+			operation, _ := point.Attributes.Value("db.operation.name")
+			failed, _ := point.Attributes.Value("error")
+			if failed.AsBool() {
+				counts["error"] += point.Count
+				continue
+			}
+			pool, _ := point.Attributes.Value(poolNameKey)
+			require.Equal(t, "default", pool.AsString())
+			system, _ := point.Attributes.Value("db.system")
+			require.Equal(t, "sqlite", system.AsString())
+			counts[operation.AsString()] += point.Count
+		}
+		require.EqualValues(t, 1, counts["create"])
+		require.EqualValues(t, 1, counts["query"])
+		require.EqualValues(t, 1, counts["error"])
+	})
+
+	t.Run("slow operations", func(t *testing.T) {
+		var total int64
+		for _, point := range metrics["db.client.operation.slow"].Data.(metricdata.Sum[int64]).DataPoints {
+			total += point.Value
+		}
+		require.Positive(t, total)
+	})
+
+	t.Run("pool stats", func(t *testing.T) {
+		points := metrics["db.client.connection.max"].Data.(metricdata.Sum[int64]).DataPoints
+		require.Len(t, points, 1)
+		require.EqualValues(t, 1, points[0].Value)
+
+		idle := attribute.NewSet(poolNameKey.String("default"), attribute.String("db.client.connection.state", "idle"))
+		var found bool
+		for _, point := range metrics["db.client.connection.count"].Data.(metricdata.Sum[int64]).DataPoints {
+			if point.Attributes.Equals(&idle) {
+				found = true
+				require.EqualValues(t, 1, point.Value)
+			}
+		}
+		require.True(t, found)
+	})
+}
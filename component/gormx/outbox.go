@@ -0,0 +1,304 @@
+package gormx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/goslacker/slacker/component/worker"
+	"github.com/goslacker/slacker/core/database"
+	"github.com/goslacker/slacker/core/eventbus"
+	"gorm.io/gorm"
+)
+
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxFailed    = "failed" // 超过最大重试次数
+)
+
+// OutboxMessage 发件箱中的事件, 和业务数据在同一个事务中写入
+type OutboxMessage struct {
+	ID            uint64    `gorm:"primaryKey"`
+	EventID       string    `gorm:"size:36;uniqueIndex"` // 去重id, 投递至少一次, 消费者可以据此去重
+	Topic         string    `gorm:"size:255"`
+	Payload       []byte    // 由方言决定列类型, 如 mysql 为 longblob, postgres 为 bytea
+	Status        string    `gorm:"size:16;index:idx_outbox_status_next"`
+	Attempts      int       // 已投递次数
+	NextAttemptAt time.Time `gorm:"index:idx_outbox_status_next"`
+	LastError     string    `gorm:"size:1024"`
+	CreatedAt     time.Time `gorm:"autoCreateTime:false"`
+	DeliveredAt   *time.Time
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// OutboxPublisher 投递发件箱中的事件
+type OutboxPublisher interface {
+	Publish(ctx context.Context, msg *OutboxMessage) error
+}
+
+type OutboxPublisherFunc func(ctx context.Context, msg *OutboxMessage) error
+
+func (f OutboxPublisherFunc) Publish(ctx context.Context, msg *OutboxMessage) error {
+	return f(ctx, msg)
+}
+
+// outboxEvents topic -> 事件类型
+var outboxEvents sync.Map
+
+// RegisterOutboxEvent 注册事件类型, 使 EventbusPublisher 可以还原事件, 通过 Outbox.Add 写入的事件会自动注册
+// 进程重启后需要重试的事件类型应在启动时注册
+func RegisterOutboxEvent[T any]() {
+	var event T
+	outboxEvents.Store(OutboxTopic(event), reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// OutboxTopic 事件实现了 Topic() string 时使用其返回值, 否则使用类型名
+func OutboxTopic(event any) string {
+	if e, ok := event.(interface{ Topic() string }); ok {
+		return e.Topic()
+	}
+	return reflect.TypeOf(event).String()
+}
+
+// EventbusPublisher 还原事件并发送到事件总线
+func EventbusPublisher(bus *eventbus.Bus) OutboxPublisher {
+	return OutboxPublisherFunc(func(ctx context.Context, msg *OutboxMessage) error {
+		v, ok := outboxEvents.Load(msg.Topic)
+		if !ok {
+			return fmt.Errorf("outbox event <%s> is not registered", msg.Topic)
+		}
+		event := reflect.New(v.(reflect.Type))
+		err := json.Unmarshal(msg.Payload, event.Interface())
+		if err != nil {
+			return fmt.Errorf("decode outbox event <%s> failed: %w", msg.Topic, err)
+		}
+		return bus.Fire(event.Elem().Interface())
+	})
+}
+
+type OutboxOpts struct {
+	Publisher   OutboxPublisher // 默认发送到默认事件总线
+	BatchSize   int             // 每次重试的最大条数, 默认 100
+	Interval    time.Duration   // 重试间隔, 默认 5s
+	MaxAttempts int             // 最大投递次数, 超过后标记为 failed, 默认 10
+	Lease       time.Duration   // 投递前锁定的时长, 期间其他实例不会投递, 默认 30s
+	Backoff     func(attempts int) time.Duration
+}
+
+func WithOutboxPublisher(publisher OutboxPublisher) func(*OutboxOpts) {
+	return func(opts *OutboxOpts) {
+		opts.Publisher = publisher
+	}
+}
+
+func WithOutboxRetry(maxAttempts int, backoff func(attempts int) time.Duration) func(*OutboxOpts) {
+	return func(opts *OutboxOpts) {
+		opts.MaxAttempts = maxAttempts
+		opts.Backoff = backoff
+	}
+}
+
+func WithOutboxInterval(interval time.Duration, batchSize int) func(*OutboxOpts) {
+	return func(opts *OutboxOpts) {
+		opts.Interval = interval
+		opts.BatchSize = batchSize
+	}
+}
+
+// ExponentialBackoff 从 base 开始指数退避, 最大为 max
+func ExponentialBackoff(base time.Duration, max time.Duration) func(attempts int) time.Duration {
+	return func(attempts int) time.Duration {
+		d := base
+		for i := 1; i < attempts && d < max; i++ {
+			d *= 2
+		}
+		return min(d, max)
+	}
+}
+
+// Outbox 事务发件箱, 事件在事务提交后才投递, 投递失败的由 Worker 按退避时间重试
+type Outbox struct {
+	db   *gorm.DB
+	opts OutboxOpts
+}
+
+func NewOutbox(db *gorm.DB, opts ...func(*OutboxOpts)) *Outbox {
+	o := &Outbox{
+		db: db,
+		opts: OutboxOpts{
+			BatchSize:   100,
+			Interval:    5 * time.Second,
+			MaxAttempts: 10,
+			Lease:       30 * time.Second,
+			Backoff:     ExponentialBackoff(time.Second, 10*time.Minute),
+		},
+	}
+	for _, opt := range opts {
+		opt(&o.opts)
+	}
+	if o.opts.Publisher == nil {
+		o.opts.Publisher = EventbusPublisher(eventbus.Default())
+	}
+	return o
+}
+
+// Migrate 创建发件箱表
+func (o *Outbox) Migrate() error {
+	return o.db.AutoMigrate(&OutboxMessage{})
+}
+
+// Add 在上下文中的事务里写入事件, 事务提交后投递, 回滚时丢弃; 没有事务时写入后立即投递
+// 不是通过 DB 开启的事务只写入事件, 由 Relay 投递
+func (o *Outbox) Add(ctx context.Context, events ...any) (err error) {
+	if len(events) == 0 {
+		return
+	}
+	db := o.db.WithContext(ctx)
+	if tx, ok := ctx.Value(database.TxKey).(*gorm.DB); ok {
+		db = tx.WithContext(ctx)
+	}
+
+	now := time.Now()
+	msgs := make([]*OutboxMessage, 0, len(events))
+	for _, event := range events {
+		topic := OutboxTopic(event)
+		outboxEvents.LoadOrStore(topic, reflect.TypeOf(event))
+		var payload []byte
+		payload, err = json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode outbox event <%s> failed: %w", topic, err)
+		}
+		msgs = append(msgs, &OutboxMessage{
+			EventID:       uuid.NewString(),
+			Topic:         topic,
+			Payload:       payload,
+			Status:        OutboxPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	err = db.Create(&msgs).Error
+	if err != nil {
+		return
+	}
+
+	// 投递不应使用已经结束的事务, 也不应被请求取消
+	deliverCtx := context.WithValue(context.WithoutCancel(ctx), database.TxKey, nil)
+	registered := AfterCommit(ctx, func() {
+		for _, msg := range msgs {
+			if err := o.deliver(deliverCtx, msg); err != nil {
+				slog.Warn("deliver outbox event failed, will retry later", "topic", msg.Topic, "event_id", msg.EventID, "error", err)
+			}
+		}
+	})
+	if !registered {
+		// 不是通过 DB 开启的事务, 无法在提交后投递, 交给 Relay 投递
+		slog.Debug("outbox events added in untracked transaction, left for relay", "count", len(msgs))
+	}
+	return
+}
+
+// Relay 投递一批到期的事件, 返回投递成功的数量
+func (o *Outbox) Relay(ctx context.Context) (delivered int, err error) {
+	var msgs []*OutboxMessage
+	err = o.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", OutboxPending, time.Now()).
+		Order("id").
+		Limit(o.opts.BatchSize).
+		Find(&msgs).Error
+	if err != nil {
+		return
+	}
+	for _, msg := range msgs {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+		if e := o.deliver(ctx, msg); e != nil {
+			slog.Warn("deliver outbox event failed", "topic", msg.Topic, "event_id", msg.EventID, "attempts", msg.Attempts, "error", e)
+			continue
+		}
+		if msg.Status == OutboxDelivered {
+			delivered++
+		}
+	}
+	return
+}
+
+// Worker 定时重试投递失败的事件, 可以注册到 worker.Manager
+func (o *Outbox) Worker() worker.Worker {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(o.opts.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := o.Relay(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("relay outbox events failed", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// deliver 锁定并投递事件, 已被其他实例锁定或投递时跳过
+func (o *Outbox) deliver(ctx context.Context, msg *OutboxMessage) (err error) {
+	now := time.Now()
+	db := o.db.WithContext(ctx).Model(&OutboxMessage{}).Session(&gorm.Session{})
+	result := db.Where("id = ? AND status = ? AND next_attempt_at <= ?", msg.ID, OutboxPending, now).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": now.Add(o.opts.Lease),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	msg.Attempts++
+
+	err = o.publish(ctx, msg)
+	if err == nil {
+		msg.Status = OutboxDelivered
+		msg.DeliveredAt = &now
+		return db.Where("id = ?", msg.ID).Updates(map[string]any{
+			"status":       OutboxDelivered,
+			"delivered_at": now,
+		}).Error
+	}
+
+	msg.LastError = err.Error()
+	if len(msg.LastError) > 1024 {
+		msg.LastError = msg.LastError[:1024]
+	}
+	msg.NextAttemptAt = now.Add(o.opts.Backoff(msg.Attempts))
+	if msg.Attempts >= o.opts.MaxAttempts {
+		msg.Status = OutboxFailed
+	}
+	e := db.Where("id = ?", msg.ID).Updates(map[string]any{
+		"status":          msg.Status,
+		"last_error":      msg.LastError,
+		"next_attempt_at": msg.NextAttemptAt,
+	}).Error
+	if e != nil {
+		slog.Error("update outbox event failed", "event_id", msg.EventID, "error", e)
+	}
+	return
+}
+
+func (o *Outbox) publish(ctx context.Context, msg *OutboxMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("publish outbox event panic: %v", r)
+		}
+	}()
+	return o.opts.Publisher.Publish(ctx, msg)
+}
@@ -0,0 +1,219 @@
+package gormx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goslacker/slacker/core/database"
+	"github.com/goslacker/slacker/core/eventbus"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type orderPaid struct {
+	OrderID int `json:"order_id"`
+}
+
+func TestAfterCommit(t *testing.T) {
+	_, gdb := newFakeDB(t)
+	db := NewHolder(gdb)
+
+	var calls []string
+	t.Run("run after commit", func(t *testing.T) {
+		calls = nil
+		err := db.Transaction(func(ctx context.Context) error {
+			AfterCommit(ctx, func() { calls = append(calls, "outer") })
+			err := db.TransactionCtx(ctx, func(ctx context.Context) error {
+				AfterCommit(ctx, func() { calls = append(calls, "nested") })
+				return nil
+			})
+			require.NoError(t, err)
+			require.Empty(t, calls)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"outer", "nested"}, calls)
+	})
+
+	t.Run("discard on rollback", func(t *testing.T) {
+		calls = nil
+		err := db.Transaction(func(ctx context.Context) error {
+			AfterCommit(ctx, func() { calls = append(calls, "outer") })
+			err := db.TransactionCtx(ctx, func(ctx context.Context) error {
+				AfterCommit(ctx, func() { calls = append(calls, "nested") })
+				return errors.New("nested failed")
+			})
+			require.Error(t, err)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"outer"}, calls)
+
+		calls = nil
+		err = db.Transaction(func(ctx context.Context) error {
+			AfterCommit(ctx, func() { calls = append(calls, "outer") })
+			return errors.New("failed")
+		})
+		require.Error(t, err)
+		require.Empty(t, calls)
+
+		ctx, err := db.Begin()
+		require.NoError(t, err)
+		AfterCommit(ctx, func() { calls = append(calls, "manual") })
+		require.NoError(t, db.Rollback(ctx))
+		require.Empty(t, calls)
+	})
+
+	t.Run("manual commit", func(t *testing.T) {
+		calls = nil
+		ctx, err := db.Begin()
+		require.NoError(t, err)
+		AfterCommit(ctx, func() { calls = append(calls, "manual") })
+		require.Empty(t, calls)
+		require.NoError(t, db.Commit(ctx))
+		require.Equal(t, []string{"manual"}, calls)
+	})
+
+	t.Run("raw gorm transaction", func(t *testing.T) {
+		calls = nil
+		err := gdb.Transaction(func(tx *gorm.DB) error {
+			ctx := context.WithValue(context.Background(), database.TxKey, tx)
+			require.False(t, AfterCommit(ctx, func() { calls = append(calls, "raw") }))
+			require.Zero(t, countTxHooks(tx.Statement.ConnPool))
+			return nil
+		})
+		require.NoError(t, err)
+		// 无法得知何时提交, 不会执行
+		require.Empty(t, calls)
+		txHooks.Range(func(key, value any) bool {
+			t.Fatalf("tx hooks leaked: %v", key)
+			return false
+		})
+	})
+
+	t.Run("without transaction", func(t *testing.T) {
+		calls = nil
+		AfterCommit(context.Background(), func() { calls = append(calls, "now") })
+		require.Equal(t, []string{"now"}, calls)
+	})
+}
+
+func TestOutbox(t *testing.T) {
+	fake, gdb := newFakeDB(t)
+	db := NewHolder(gdb)
+	var published []*OutboxMessage
+	var publishErr error
+	outbox := NewOutbox(gdb, WithOutboxPublisher(OutboxPublisherFunc(func(ctx context.Context, msg *OutboxMessage) error {
+		published = append(published, msg)
+		return publishErr
+	})))
+
+	t.Run("deliver after commit", func(t *testing.T) {
+		published = nil
+		fake.statements()
+		err := db.Transaction(func(ctx context.Context) error {
+			require.NoError(t, outbox.Add(ctx, orderPaid{OrderID: 1}))
+			require.Empty(t, published)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, published, 1)
+		msg := published[0]
+		require.Equal(t, "gormx.orderPaid", msg.Topic)
+		require.JSONEq(t, `{"order_id":1}`, string(msg.Payload))
+		require.NotEmpty(t, msg.EventID)
+		require.Equal(t, OutboxDelivered, msg.Status)
+		require.Equal(t, 1, msg.Attempts)
+
+		stmts := fake.statements()
+		require.Equal(t, "BEGIN", stmts[0])
+		require.True(t, strings.HasPrefix(stmts[1], "INSERT INTO `outbox_messages`"))
+		require.Equal(t, "COMMIT", stmts[2])
+		// 提交后先锁定再标记为已投递
+		var updates []string
+		for _, stmt := range stmts[3:] {
+			if strings.HasPrefix(stmt, "UPDATE") {
+				updates = append(updates, stmt)
+			}
+		}
+		require.Len(t, updates, 2)
+		require.Contains(t, updates[0], "`attempts`=attempts + 1")
+		require.Contains(t, updates[1], "`status`=")
+	})
+
+	t.Run("raw gorm transaction left for relay", func(t *testing.T) {
+		published = nil
+		fake.statements()
+		err := gdb.Transaction(func(tx *gorm.DB) error {
+			ctx := context.WithValue(context.Background(), database.TxKey, tx)
+			return outbox.Add(ctx, orderPaid{OrderID: 4})
+		})
+		require.NoError(t, err)
+		require.Empty(t, published)
+		stmts := fake.statements()
+		require.Len(t, stmts, 3)
+		require.Equal(t, "COMMIT", stmts[2])
+	})
+
+	t.Run("discard on rollback", func(t *testing.T) {
+		published = nil
+		err := db.Transaction(func(ctx context.Context) error {
+			require.NoError(t, outbox.Add(ctx, orderPaid{OrderID: 2}))
+			return errors.New("rollback")
+		})
+		require.Error(t, err)
+		require.Empty(t, published)
+	})
+
+	t.Run("retry failed", func(t *testing.T) {
+		published = nil
+		publishErr = errors.New("broker down")
+		defer func() { publishErr = nil }()
+		require.NoError(t, db.Transaction(func(ctx context.Context) error {
+			return outbox.Add(ctx, orderPaid{OrderID: 3})
+		}))
+		require.Len(t, published, 1)
+		require.Equal(t, OutboxPending, published[0].Status)
+		require.Equal(t, "broker down", published[0].LastError)
+		require.True(t, published[0].NextAttemptAt.After(time.Now()))
+
+		fake.rows = func(query string) ([]string, [][]driver.Value) {
+			return []string{"id", "event_id", "topic", "payload", "status", "attempts"},
+				[][]driver.Value{{int64(3), "e3", "gormx.orderPaid", []byte(`{"order_id":3}`), OutboxPending, int64(9)}}
+		}
+		defer func() { fake.rows = nil }()
+		delivered, err := outbox.Relay(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, delivered)
+		require.Len(t, published, 2)
+		require.Equal(t, "e3", published[1].EventID)
+		require.Equal(t, OutboxFailed, published[1].Status)
+
+		publishErr = nil
+		delivered, err = outbox.Relay(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, delivered)
+	})
+}
+
+func TestEventbusPublisher(t *testing.T) {
+	RegisterOutboxEvent[orderPaid]()
+	bus := eventbus.NewBus()
+	var received []orderPaid
+	eventbus.SubscribeTo(bus, func(e orderPaid) error {
+		received = append(received, e)
+		return nil
+	})
+	publisher := EventbusPublisher(bus)
+
+	err := publisher.Publish(context.Background(), &OutboxMessage{Topic: "gormx.orderPaid", Payload: []byte(`{"order_id":5}`)})
+	require.NoError(t, err)
+	require.Equal(t, []orderPaid{{OrderID: 5}}, received)
+
+	err = publisher.Publish(context.Background(), &OutboxMessage{Topic: "unknown"})
+	require.EqualError(t, err, "outbox event <unknown> is not registered")
+}
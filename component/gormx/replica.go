@@ -0,0 +1,114 @@
+package gormx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type usePrimaryKey struct{}
+
+// UsePrimary 标记上下文中的只读查询也使用主库, 用于写后立即读等不能容忍复制延迟的场景
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usePrimaryKey{}, true)
+}
+
+// Replicas 只读副本, 轮询选择健康的副本, 定期检查健康状态
+type Replicas struct {
+	dbs     []*gorm.DB
+	healthy []atomic.Bool
+	next    atomic.Uint64
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewReplicas 创建只读副本, 初始时都视为健康
+func NewReplicas(dbs ...*gorm.DB) *Replicas {
+	r := &Replicas{
+		dbs:     dbs,
+		healthy: make([]atomic.Bool, len(dbs)),
+	}
+	for i := range r.healthy {
+		r.healthy[i].Store(true)
+	}
+	return r
+}
+
+// Pick 轮询返回一个健康的副本, 没有健康的副本时返回nil
+func (r *Replicas) Pick() *gorm.DB {
+	if r == nil || len(r.dbs) == 0 {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := 0; i < len(r.dbs); i++ {
+		idx := int((start + uint64(i)) % uint64(len(r.dbs)))
+		if r.healthy[idx].Load() {
+			return r.dbs[idx]
+		}
+	}
+	return nil
+}
+
+// Check ping 所有副本并更新健康状态
+func (r *Replicas) Check(ctx context.Context) {
+	for i, db := range r.dbs {
+		err := ping(ctx, db)
+		if err != nil && r.healthy[i].Load() {
+			slog.Warn("database replica is unhealthy, fallback to other replicas or primary", "replica", i, "error", err)
+		}
+		if err == nil && !r.healthy[i].Load() {
+			slog.Info("database replica is healthy again", "replica", i)
+		}
+		r.healthy[i].Store(err == nil)
+	}
+}
+
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// Watch 每隔 interval 检查一次副本的健康状态, 直到 Close
+func (r *Replicas) Watch(interval time.Duration) {
+	var ctx context.Context
+	ctx, r.cancel = context.WithCancel(context.Background())
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Check(ctx)
+			}
+		}
+	}()
+}
+
+// Close 停止健康检查并关闭副本的连接
+func (r *Replicas) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+		r.wg.Wait()
+	}
+	var errs []error
+	for _, db := range r.dbs {
+		if sqlDB, err := db.DB(); err == nil {
+			errs = append(errs, sqlDB.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -1,10 +1,19 @@
 package gormx
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/goslacker/slacker/core/app"
+	"github.com/goslacker/slacker/core/lock"
 	"gorm.io/gorm"
 )
 
+// seedLockKey 多个实例同时启动时只有一个执行种子数据
+const seedLockKey = "slacker.seed"
+
 func NewSeedComponent() *SeedComponent {
 	return &SeedComponent{}
 }
@@ -13,6 +22,15 @@ type SeedComponent struct {
 	app.Component
 }
 
+func (s *SeedComponent) Name() string {
+	return "gormx.seed"
+}
+
+// OptionalDependsOn 填充数据使用 gormx 的连接
+func (s *SeedComponent) OptionalDependsOn() []string {
+	return []string{"gormx"}
+}
+
 func (s *SeedComponent) Init() (err error) {
 	err = app.Bind[*SeedManager](func() *SeedManager {
 		return &SeedManager{}
@@ -32,6 +50,17 @@ func (s *SeedComponent) Boot() (err error) {
 	if err != nil {
 		return
 	}
+	locker, err := app.Resolve[lock.Locker]()
+	if err != nil {
+		return
+	}
+	unlock, err := locker.Lock(context.Background(), seedLockKey)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err = errors.Join(err, unlock())
+	}()
 	err = manager.Seed(db)
 	if err != nil {
 		return
@@ -43,19 +72,72 @@ func (s *SeedComponent) Seed(db *gorm.DB) error {
 	return s.Seed(db)
 }
 
+// seedHistory 已执行的命名种子数据
+type seedHistory struct {
+	Name      string `gorm:"primaryKey;size:128"`
+	CreatedAt time.Time
+}
+
+func (seedHistory) TableName() string {
+	return "seed_histories"
+}
+
+type namedSeed struct {
+	name string
+	seed func(db *gorm.DB) error
+}
+
+// SeedManager Seeds 每次启动都执行, 命名种子数据在每个环境只执行一次
 type SeedManager struct {
 	Seeds []func(db *gorm.DB) error
+	named []namedSeed
 }
 
 func (s *SeedManager) RegisterSeed(seeds ...func(db *gorm.DB) error) {
 	s.Seeds = append(s.Seeds, seeds...)
 }
 
+// RegisterNamedSeed 注册命名种子数据, 执行成功后记录到 seed_histories, 之后不再执行
+func (s *SeedManager) RegisterNamedSeed(name string, seed func(db *gorm.DB) error) {
+	s.named = append(s.named, namedSeed{name: name, seed: seed})
+}
+
 func (s *SeedManager) Seed(db *gorm.DB) error {
 	for _, seed := range s.Seeds {
 		if err := seed(db); err != nil {
 			return err
 		}
 	}
+	if len(s.named) == 0 {
+		return nil
+	}
+
+	if err := db.AutoMigrate(&seedHistory{}); err != nil {
+		return fmt.Errorf("migrate seed histories failed: %w", err)
+	}
+	var ran []string
+	if err := db.Model(&seedHistory{}).Pluck("name", &ran).Error; err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(ran)+len(s.named))
+	for _, name := range ran {
+		done[name] = true
+	}
+	for _, seed := range s.named {
+		if done[seed.name] {
+			continue
+		}
+		// 种子数据和执行记录在同一个事务中, 失败时下次启动重新执行
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := seed.seed(tx); err != nil {
+				return err
+			}
+			return tx.Create(&seedHistory{Name: seed.name}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("seed <%s> failed: %w", seed.name, err)
+		}
+		done[seed.name] = true
+	}
 	return nil
 }
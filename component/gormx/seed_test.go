@@ -0,0 +1,76 @@
+package gormx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goslacker/slacker/core/app"
+	"github.com/goslacker/slacker/core/container"
+	"github.com/goslacker/slacker/core/lock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSeedComponent(t *testing.T) {
+	container.Set(container.NewContainer())
+	defer container.Set(nil)
+	require.NoError(t, app.LoadConfig(app.WithContent(`
+gormx:
+  dsn: sqlite://file:seed?mode=memory&cache=shared
+`)))
+	c := NewComponent()
+	require.NoError(t, c.Init())
+	s := NewSeedComponent()
+	require.NoError(t, s.Init())
+
+	locker, err := app.Resolve[lock.Locker]()
+	require.NoError(t, err)
+	require.IsType(t, &lock.MemoryLocker{}, locker)
+
+	db, err := app.Resolve[*gorm.DB]()
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&item{}))
+
+	manager, err := app.Resolve[*SeedManager]()
+	require.NoError(t, err)
+	var always, failed int
+	manager.RegisterSeed(func(db *gorm.DB) error {
+		always++
+		return nil
+	})
+	manager.RegisterNamedSeed("items", func(db *gorm.DB) error {
+		return db.Create([]*item{{Name: "a"}, {Name: "b"}}).Error
+	})
+	manager.RegisterNamedSeed("broken", func(db *gorm.DB) error {
+		failed++
+		if err := db.Create(&item{Name: "c"}).Error; err != nil {
+			return err
+		}
+		if failed == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	count := func() (n int64) {
+		require.NoError(t, db.Model(&item{}).Count(&n).Error)
+		return
+	}
+
+	t.Run("failed seed rolled back", func(t *testing.T) {
+		require.EqualError(t, s.Boot(), "seed <broken> failed: boom")
+		require.EqualValues(t, 2, count())
+	})
+
+	t.Run("named seeds run once", func(t *testing.T) {
+		require.NoError(t, s.Boot())
+		require.NoError(t, s.Boot())
+		require.EqualValues(t, 3, count())
+		require.Equal(t, 3, always)
+		require.Equal(t, 2, failed)
+
+		var names []string
+		require.NoError(t, db.Model(&seedHistory{}).Order("name").Pluck("name", &names).Error)
+		require.Equal(t, []string{"broken", "items"}, names)
+	})
+}
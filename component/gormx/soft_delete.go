@@ -0,0 +1,171 @@
+package gormx
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type withTrashedKey struct{}
+
+// WithTrashed 标记上下文中 HolderRepository 的查询包含已软删除的记录, 删除时永久删除
+func WithTrashed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withTrashedKey{}, true)
+}
+
+func withTrashed(ctx context.Context) bool {
+	v, _ := ctx.Value(withTrashedKey{}).(bool)
+	return v
+}
+
+// SoftDelete 嵌入后启用软删除, 删除时记录删除时间, 查询时自动过滤已删除的记录
+type SoftDelete struct {
+	DeletedAt DeletedAt `json:"deleted_at" gorm:"not null;default:0;index"`
+}
+
+// DeletedAt 毫秒时间戳, 0 表示未删除, 与 UnixTimestampMilli 一致
+type DeletedAt int64
+
+func (d *DeletedAt) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*d = 0
+	case int64:
+		*d = DeletedAt(v)
+	case []byte:
+		var n int64
+		if _, err := fmt.Sscan(string(v), &n); err != nil {
+			return err
+		}
+		*d = DeletedAt(n)
+	default:
+		return fmt.Errorf("can not scan %T into DeletedAt", value)
+	}
+	return nil
+}
+
+func (d DeletedAt) Value() (driver.Value, error) {
+	return int64(d), nil
+}
+
+func (DeletedAt) QueryClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{softDeleteQueryClause{field: f}}
+}
+
+func (DeletedAt) UpdateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{softDeleteUpdateClause{field: f}}
+}
+
+func (DeletedAt) DeleteClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{softDeleteDeleteClause{field: f}}
+}
+
+var deletedAtType = reflect.TypeOf(DeletedAt(0))
+
+// deletedAtField 模型的软删除字段, 没有嵌入 SoftDelete 时返回nil
+func deletedAtField(s *schema.Schema) *schema.Field {
+	for _, f := range s.Fields {
+		if f.FieldType == deletedAtType {
+			return f
+		}
+	}
+	return nil
+}
+
+type softDeleteQueryClause struct {
+	field *schema.Field
+}
+
+func (sd softDeleteQueryClause) Name() string {
+	return ""
+}
+
+func (sd softDeleteQueryClause) Build(clause.Builder) {
+}
+
+func (sd softDeleteQueryClause) MergeClause(*clause.Clause) {
+}
+
+func (sd softDeleteQueryClause) ModifyStatement(stmt *gorm.Statement) {
+	if _, ok := stmt.Clauses["soft_delete_enabled"]; ok || stmt.Unscoped {
+		return
+	}
+	// 条件中有 Or 时先整体用括号包起来, 避免与软删除条件优先级混淆
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) >= 1 {
+			for _, expr := range where.Exprs {
+				if orCond, ok := expr.(clause.OrConditions); ok && len(orCond.Exprs) == 1 {
+					where.Exprs = []clause.Expression{clause.And(where.Exprs...)}
+					c.Expression = where
+					stmt.Clauses["WHERE"] = c
+					break
+				}
+			}
+		}
+	}
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: sd.field.DBName}, Value: 0},
+	}})
+	stmt.Clauses["soft_delete_enabled"] = clause.Clause{}
+}
+
+type softDeleteUpdateClause struct {
+	field *schema.Field
+}
+
+func (sd softDeleteUpdateClause) Name() string {
+	return ""
+}
+
+func (sd softDeleteUpdateClause) Build(clause.Builder) {
+}
+
+func (sd softDeleteUpdateClause) MergeClause(*clause.Clause) {
+}
+
+func (sd softDeleteUpdateClause) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.SQL.Len() == 0 && !stmt.Unscoped {
+		softDeleteQueryClause(sd).ModifyStatement(stmt)
+	}
+}
+
+type softDeleteDeleteClause struct {
+	field *schema.Field
+}
+
+func (sd softDeleteDeleteClause) Name() string {
+	return ""
+}
+
+func (sd softDeleteDeleteClause) Build(clause.Builder) {
+}
+
+func (sd softDeleteDeleteClause) MergeClause(*clause.Clause) {
+}
+
+// ModifyStatement 把删除改为更新删除时间, Unscoped 时永久删除
+func (sd softDeleteDeleteClause) ModifyStatement(stmt *gorm.Statement) {
+	if stmt.SQL.Len() > 0 || stmt.Unscoped {
+		return
+	}
+	now := DeletedAt(stmt.DB.NowFunc().UnixMilli())
+	stmt.AddClause(clause.Set{{Column: clause.Column{Name: sd.field.DBName}, Value: now}})
+	stmt.SetColumn(sd.field.DBName, now, true)
+
+	if stmt.Schema != nil {
+		_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
+		column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+		if len(values) > 0 {
+			stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+		}
+	}
+
+	softDeleteQueryClause(sd).ModifyStatement(stmt)
+	stmt.AddClauseIfNotExists(clause.Update{})
+	stmt.Build(stmt.DB.Callback().Update().Clauses...)
+}
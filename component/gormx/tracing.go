@@ -0,0 +1,91 @@
+package gormx
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	tracerName   = "github.com/goslacker/slacker/component/gormx"
+	tracingSpan  = "gormx:tracing_span"
+	maxQueryText = 4096
+)
+
+// tracingPlugin 为每条语句创建上下文中 span 的子 span, 上下文中没有 span 时不记录, 使链路可以从网关经过 grpc 串到 sql
+type tracingPlugin struct{}
+
+func (p *tracingPlugin) Name() string {
+	return "gormx:tracing"
+}
+
+func (p *tracingPlugin) Initialize(db *gorm.DB) (err error) {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("gormx:tracing_before_create", p.before("create")),
+		cb.Create().After("*").Register("gormx:tracing_after_create", p.after),
+		cb.Query().Before("*").Register("gormx:tracing_before_query", p.before("query")),
+		cb.Query().After("*").Register("gormx:tracing_after_query", p.after),
+		cb.Update().Before("*").Register("gormx:tracing_before_update", p.before("update")),
+		cb.Update().After("*").Register("gormx:tracing_after_update", p.after),
+		cb.Delete().Before("*").Register("gormx:tracing_before_delete", p.before("delete")),
+		cb.Delete().After("*").Register("gormx:tracing_after_delete", p.after),
+		cb.Row().Before("*").Register("gormx:tracing_before_row", p.before("row")),
+		cb.Row().After("*").Register("gormx:tracing_after_row", p.after),
+		cb.Raw().Before("*").Register("gormx:tracing_before_raw", p.before("raw")),
+		cb.Raw().After("*").Register("gormx:tracing_after_raw", p.after),
+	)
+}
+
+func (p *tracingPlugin) before(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		parent := trace.SpanFromContext(ctx)
+		if !parent.SpanContext().IsValid() {
+			return
+		}
+		attrs := []attribute.KeyValue{
+			semconv.DBSystemKey.String(tx.Dialector.Name()),
+			semconv.DBOperationNameKey.String(operation),
+		}
+		name := operation
+		// 执行回调前已解析模型, 原生 sql 没有表名
+		if table := tx.Statement.Table; table != "" {
+			name += " " + table
+			attrs = append(attrs, semconv.DBCollectionNameKey.String(table))
+		}
+		// grpc 拦截器按服务切换全局 provider, 使用父 span 的 provider 保证上报到同一个服务
+		_, span := parent.TracerProvider().Tracer(tracerName).Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+		tx.InstanceSet(tracingSpan, span)
+	}
+}
+
+func (p *tracingPlugin) after(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(tracingSpan)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	// 只记录带占位符的语句, 不包含参数值
+	query := tx.Statement.SQL.String()
+	if len(query) > maxQueryText {
+		query = query[:maxQueryText]
+	}
+	span.SetAttributes(
+		semconv.DBQueryTextKey.String(query),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(tx.Error)
+		span.SetStatus(codes.Error, tx.Error.Error())
+	}
+}
@@ -0,0 +1,57 @@
+package gormx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	traceSdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingPlugin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := traceSdk.NewTracerProvider(traceSdk.WithSpanProcessor(recorder))
+
+	db := newTestDB(t)
+	require.NoError(t, db.Use(&tracingPlugin{}))
+	require.NoError(t, db.AutoMigrate(&item{}))
+
+	t.Run("without parent span", func(t *testing.T) {
+		require.NoError(t, db.Create(&item{Name: "a"}).Error)
+		require.Empty(t, recorder.Ended())
+	})
+
+	t.Run("child of context span", func(t *testing.T) {
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "rpc")
+		repo := NewHolderRepository[item, item, itemCondition](NewHolder(db), parseItemCondition)
+		_, err := repo.List(ctx, itemCondition{Name: "secret"})
+		require.NoError(t, err)
+		require.Error(t, db.WithContext(ctx).Table("missing").Find(&[]item{}).Error)
+		parent.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 3)
+		query, failed := spans[0], spans[1]
+		require.Equal(t, "query items", query.Name())
+		require.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+		require.Equal(t, parent.SpanContext().TraceID(), query.SpanContext().TraceID())
+
+		attrs := attribute.NewSet(query.Attributes()...)
+		table, _ := attrs.Value("db.collection.name")
+		require.Equal(t, "items", table.AsString())
+		operation, _ := attrs.Value("db.operation.name")
+		require.Equal(t, "query", operation.AsString())
+		rows, _ := attrs.Value("db.rows_affected")
+		require.EqualValues(t, 0, rows.AsInt64())
+		text, _ := attrs.Value("db.query.text")
+		require.Contains(t, text.AsString(), "name = ?")
+		require.NotContains(t, text.AsString(), "secret")
+
+		require.Equal(t, "query missing", failed.Name())
+		require.Equal(t, codes.Error, failed.Status().Code)
+		require.NotEmpty(t, failed.Events())
+	})
+}
@@ -0,0 +1,86 @@
+package gormx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/goslacker/slacker/core/database"
+	"gorm.io/gorm"
+)
+
+// txHooks 事务连接 -> 提交后执行的函数, 只记录通过 DB 开启的事务, 在提交或回滚时删除
+var txHooks sync.Map
+
+type txHookList struct {
+	lock  sync.Mutex
+	hooks []func()
+}
+
+// AfterCommit 注册在上下文中的事务提交后执行的函数, 事务回滚时丢弃, 上下文中没有事务时立即执行
+// 只对通过 DB.Transaction, DB.Begin 开启的事务生效, 直接使用 gorm.DB.Transaction 等开启的事务无法得知何时提交,
+// 不会执行 f 并返回 false; DB.Begin 开启的事务需要通过 DB.Commit 或 DB.Rollback 结束
+func AfterCommit(ctx context.Context, f func()) bool {
+	tx, ok := ctx.Value(database.TxKey).(*gorm.DB)
+	if !ok || !inTransaction(tx) {
+		f()
+		return true
+	}
+	v, ok := txHooks.Load(tx.Statement.ConnPool)
+	if !ok {
+		return false
+	}
+	list := v.(*txHookList)
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.hooks = append(list.hooks, f)
+	return true
+}
+
+// beginTxHooks 记录通过 DB 开启的事务
+func beginTxHooks(pool gorm.ConnPool) {
+	txHooks.Store(pool, &txHookList{})
+}
+
+func inTransaction(db *gorm.DB) bool {
+	committer, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok && committer != nil
+}
+
+func countTxHooks(pool gorm.ConnPool) int {
+	v, ok := txHooks.Load(pool)
+	if !ok {
+		return 0
+	}
+	list := v.(*txHookList)
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	return len(list.hooks)
+}
+
+// truncateTxHooks 嵌套事务回滚时丢弃其中注册的函数
+func truncateTxHooks(pool gorm.ConnPool, n int) {
+	v, ok := txHooks.Load(pool)
+	if !ok {
+		return
+	}
+	list := v.(*txHookList)
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	if n < len(list.hooks) {
+		list.hooks = list.hooks[:n]
+	}
+}
+
+func discardTxHooks(pool gorm.ConnPool) {
+	txHooks.Delete(pool)
+}
+
+func runTxHooks(pool gorm.ConnPool) {
+	v, ok := txHooks.LoadAndDelete(pool)
+	if !ok {
+		return
+	}
+	for _, f := range v.(*txHookList).hooks {
+		f()
+	}
+}
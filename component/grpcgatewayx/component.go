@@ -2,10 +2,14 @@ package grpcgatewayx
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/goslacker/slacker/component/grpcgatewayx/annotator"
 	"github.com/goslacker/slacker/component/grpcgatewayx/middleware"
@@ -19,7 +23,6 @@ import (
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/rs/cors"
-	"github.com/spf13/viper"
 	"google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -33,6 +36,11 @@ func WithRegisters(registers ...func(ctx context.Context, mux *runtime.ServeMux,
 	}
 }
 
+type config struct {
+	Endpoint string `mapstructure:"endpoint" validate:"required"`
+	Addr     string `mapstructure:"addr" validate:"required"`
+}
+
 func NewComponent(opts ...func(*Component)) *Component {
 	c := &Component{
 		handlers:     make(map[grpcgatewayx.HandlerKey]runtime.HandlerFunc),
@@ -74,6 +82,7 @@ type Component struct {
 	metadataFunc            []func(context.Context, *http.Request) metadata.MD
 	queryParser             runtime.QueryParameterParser
 	ignoreLogPaths          []string
+	ready                   atomic.Bool
 }
 
 func (c *Component) IgnoreLogPaths(paths ...string) {
@@ -113,34 +122,50 @@ func (c *Component) RegisterMetadataFunc(m ...func(context.Context, *http.Reques
 	c.metadataFunc = append(c.metadataFunc, m...)
 }
 
+func (c *Component) Name() string {
+	return "grpcgatewayx"
+}
+
+// OptionalDependsOn 网关转发到 grpcx 的服务, 在其之后启动, 之前停止
+func (c *Component) OptionalDependsOn() []string {
+	return []string{"grpcx"}
+}
+
 func (c *Component) Init() error {
 	return app.Bind[*Component](c)
 }
 
-// Start 启动服务并阻塞, 框架一般会将这个方法作为协程调用, 报错应打日志记录
+var _ app.Serviceable = (*Component)(nil)
+
+// Start 兼容旧的 Serviceable 接口, 调用 Serve 并记录错误
 func (c *Component) Start() {
+	if err := c.Serve(); err != nil {
+		slog.Error("grpcgatewayx serve failed", "error", err)
+	}
+}
+
+// Serve 启动服务并阻塞, 配置错误或端口监听失败时返回错误
+func (c *Component) Serve() (err error) {
 	if len(c.registers) <= 0 {
 		slog.Warn("no gateway register")
 		return
 	}
-	conf := viper.Sub("grpcgatewayx")
-	if conf == nil {
-		slog.Error("no grpc gateway config")
+	conf, err := app.Config[config]("grpcgatewayx")
+	if err != nil {
 		return
 	}
 
 	var ctx context.Context
 	ctx, c.cancel = context.WithCancel(context.Background())
 
-	endpoint := conf.GetString("endpoint")
+	endpoint := conf.Endpoint
 	conn, err := grpc.NewClient(
 		endpoint,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(math.MaxInt32), grpc.MaxCallSendMsgSize(math.MaxInt32)),
 	)
 	if err != nil {
-		slog.Error("Failed to dial server", "err", err)
-		return
+		return fmt.Errorf("failed to dial server: %w", err)
 	}
 	defer func() {
 		if err != nil {
@@ -208,35 +233,57 @@ func (c *Component) Start() {
 	))
 	mux := runtime.NewServeMux(options...)
 	for _, register := range c.registers {
-		err := register(ctx, mux, conn)
+		err = register(ctx, mux, conn)
 		if err != nil {
-			slog.Error("Failed to register gateway", "err", err)
+			return fmt.Errorf("failed to register gateway: %w", err)
 		}
 	}
 
 	for key, handler := range c.handlers {
-		err := mux.HandlePath(key.Method(), key.Path(), handler)
+		err = mux.HandlePath(key.Method(), key.Path(), handler)
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("failed to set customer handler(%s %s): %w", key.Method(), key.Path(), err)
 		}
 	}
 
 	withCors := cors.AllowAll().Handler(mux)
 
 	c.gwServer = &http.Server{
-		Addr:    conf.GetString("addr"),
+		Addr:    conf.Addr,
 		Handler: withCors,
 	}
 
-	slog.Info("Serving gRPC-Gateway on " + conf.GetString("addr"))
-	slog.Error("grpc gateway server shutdown", "err", c.gwServer.ListenAndServe())
+	lis, err := net.Listen("tcp", c.gwServer.Addr)
+	if err != nil {
+		return fmt.Errorf("grpc gateway listen failed: %w", err)
+	}
+	slog.Info("Serving gRPC-Gateway on " + conf.Addr)
+	c.ready.Store(true)
+	defer c.ready.Store(false)
+	err = c.gwServer.Serve(lis)
+	if errors.Is(err, http.ErrServerClosed) {
+		slog.Info("grpc gateway server shutdown")
+		return nil
+	}
+	return
+}
+
+// Ready http端口监听成功后就绪
+func (c *Component) Ready() error {
+	if !c.ready.Load() {
+		return errors.New("grpc gateway server is not serving")
+	}
+	return nil
 }
 
 // Stop 停止服务并阻塞, 报错应打日志记录
 func (c *Component) Stop() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5)
+	c.ready.Store(false)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	c.gwServer.Shutdown(ctx)
+	if c.gwServer != nil {
+		_ = c.gwServer.Shutdown(ctx)
+	}
 	if c.cancel != nil {
 		c.cancel()
 	}
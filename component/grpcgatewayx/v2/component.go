@@ -1,16 +1,19 @@
 package grpcgatewayx
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"sync/atomic"
 
 	"github.com/goslacker/slacker/core/app"
 	"github.com/goslacker/slacker/core/grpcgatewayx"
-	"github.com/spf13/viper"
 )
 
 type config struct {
-	Endpoint string `mapstructure:"endpoint"`
-	Addr     string `mapstructure:"addr"`
+	Endpoint string `mapstructure:"endpoint" validate:"required"`
+	Addr     string `mapstructure:"addr" validate:"required"`
 }
 
 func NewComponent() *Component {
@@ -19,20 +22,25 @@ func NewComponent() *Component {
 
 type Component struct {
 	app.Component
-	server *grpcgatewayx.Server
+	server atomic.Pointer[grpcgatewayx.Server]
 }
 
-func (c *Component) getConfig(conf *viper.Viper) (cfg config) {
-	cfg = config{
-		Endpoint: conf.GetString("grpcgatewayx.endpoint"),
-		Addr:     conf.GetString("grpcgatewayx.addr"),
-	}
-	return
+func (c *Component) Name() string {
+	return "grpcgatewayx"
+}
+
+// OptionalDependsOn 网关转发到 grpcx 的服务, 在其之后启动, 之前停止
+func (c *Component) OptionalDependsOn() []string {
+	return []string{"grpcx"}
 }
 
 func (c *Component) Init() (err error) {
-	err = app.Bind[*grpcgatewayx.GrpcGatewayBuilder](func(conf *viper.Viper) (builder *grpcgatewayx.GrpcGatewayBuilder, err error) {
-		cfg := c.getConfig(conf)
+	cfg, err := app.Config[config]("grpcgatewayx")
+	if err != nil {
+		return
+	}
+
+	err = app.Bind[*grpcgatewayx.GrpcGatewayBuilder](func() (builder *grpcgatewayx.GrpcGatewayBuilder, err error) {
 		b := &grpcgatewayx.GrpcGatewayBuilder{
 			Endpoint: cfg.Endpoint,
 			Addr:     cfg.Addr,
@@ -46,24 +54,48 @@ func (c *Component) Init() (err error) {
 	return
 }
 
+var _ app.Serviceable = (*Component)(nil)
+
+// Start 兼容旧的 Serviceable 接口, 调用 Serve 并记录错误
 func (c *Component) Start() {
-	builder := app.MustResolve[*grpcgatewayx.GrpcGatewayBuilder]()
-	server, err := builder.Build()
+	if err := c.Serve(); err != nil {
+		slog.Error("grpcgatewayx serve failed", "error", err)
+	}
+}
+
+// Serve 启动服务并阻塞, 构建或启动失败时返回错误
+func (c *Component) Serve() (err error) {
+	builder, err := app.Resolve[*grpcgatewayx.GrpcGatewayBuilder]()
 	if err != nil {
-		slog.Error("Failed to build grpc gateway server", "err", err)
 		return
 	}
-	if err = server.Start(); err != nil {
-		slog.Error("Failed to start grpc gateway server", "err", err)
-		return
+	server, err := builder.Build()
+	if err != nil {
+		return fmt.Errorf("failed to build grpc gateway server: %w", err)
 	}
+	c.server.Store(server)
+	err = server.Start()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return
 }
 
 func (c *Component) Stop() {
-	if c.server == nil {
+	server := c.server.Load()
+	if server == nil {
 		return
 	}
-	if err := c.server.Stop(); err != nil {
+	if err := server.Stop(); err != nil {
 		slog.Error("Failed to stop grpc gateway server", "err", err)
 	}
 }
+
+// Ready http端口监听成功后就绪
+func (c *Component) Ready() error {
+	server := c.server.Load()
+	if server == nil {
+		return errors.New("grpc gateway server is not built")
+	}
+	return server.Ready()
+}
@@ -9,16 +9,19 @@ import (
 	"net"
 	"net/http"
 	_ "net/http/pprof"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/goslacker/slacker/component/grpcx/interceptor"
 	"github.com/goslacker/slacker/core/app"
+	coreInterceptor "github.com/goslacker/slacker/core/grpcx/interceptor"
 	"github.com/goslacker/slacker/core/serviceregistry"
 	"github.com/goslacker/slacker/core/serviceregistry/registry"
 	"github.com/goslacker/slacker/core/tool"
 	"github.com/goslacker/slacker/core/trace"
 
-	"github.com/spf13/viper"
 	traceSdk "go.opentelemetry.io/otel/sdk/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
@@ -53,15 +56,28 @@ type Component struct {
 	streamServerInterceptors []grpc.StreamServerInterceptor
 	registers                []func(grpc.ServiceRegistrar)
 	pprofPort                int
+	conf                     Config
+	ready                    atomic.Bool
+}
+
+func (c *Component) Name() string {
+	return "grpcx"
 }
 
 func (c *Component) Init() (err error) {
+	c.conf, err = app.Config[Config]("grpcx")
+	if err != nil {
+		return
+	}
+
 	c.unaryServerInterceptors = []grpc.UnaryServerInterceptor{
+		coreInterceptor.UnaryScopeServerInterceptor,
 		interceptor.UnaryErrorInterceptor,
 		interceptor.UnaryValidateInterceptor,
 	}
 
 	c.streamServerInterceptors = []grpc.StreamServerInterceptor{
+		coreInterceptor.StreamScopeServerInterceptor,
 		interceptor.StreamErrorInterceptor,
 		interceptor.StreamValidateInterceptor,
 	}
@@ -85,20 +101,25 @@ func (c *Component) Register(registers ...func(grpc.ServiceRegistrar)) {
 	c.registers = append(c.registers, registers...)
 }
 
+var _ app.Serviceable = (*Component)(nil)
+
+// Start 兼容旧的 Serviceable 接口, 调用 Serve 并记录错误
 func (c *Component) Start() {
-	if len(c.registers) <= 0 {
-		panic(errors.New("no grpc service registered"))
+	if err := c.Serve(); err != nil {
+		slog.Error("grpcx serve failed", "error", err)
 	}
+}
 
-	var conf Config
-	err := viper.Sub("grpcx").Unmarshal(&conf)
-	if err != nil {
-		panic(fmt.Errorf("read config failed: %w", err))
+// Serve 启动服务并阻塞, 配置错误或端口监听失败时返回错误
+func (c *Component) Serve() (err error) {
+	if len(c.registers) <= 0 {
+		return errors.New("no grpc service registered")
 	}
 
+	conf := c.conf
 	addr, err := c.detectAddr(conf.Addr)
 	if err != nil {
-		panic(fmt.Errorf("get local ip failed: %w", err))
+		return fmt.Errorf("get local ip failed: %w", err)
 	}
 
 	if conf.Trace != nil {
@@ -120,10 +141,9 @@ func (c *Component) Start() {
 	if conf.HealthCheck {
 		healthCheck := health.NewServer()
 		healthgrpc.RegisterHealthServer(c.grpcServer, healthCheck)
-		err := app.Bind[*health.Server](healthCheck)
+		err = app.Bind[*health.Server](healthCheck)
 		if err != nil {
-			slog.Error("bind health check failed", "error", err)
-			return
+			return fmt.Errorf("bind health check failed: %w", err)
 		}
 	}
 
@@ -133,17 +153,31 @@ func (c *Component) Start() {
 
 	if conf.Trace != nil {
 		var deferFunc func()
-		interceptor.Providers, deferFunc = traceAgent(conf.Trace, c.grpcServer, addr)
+		interceptor.Providers, deferFunc, err = traceAgent(conf.Trace, c.grpcServer, addr)
+		if err != nil {
+			return
+		}
 		defer deferFunc()
+
+		if conf.Trace.Metrics {
+			deferFunc, err = meterAgent(*conf.Trace, c.grpcServer, addr)
+			if err != nil {
+				return
+			}
+			defer deferFunc()
+		}
 	}
 
 	if conf.Registry != nil {
-		registerService(conf.Registry, addr, c.grpcServer)
+		err = registerService(conf.Registry, addr, c.grpcServer)
+		if err != nil {
+			return
+		}
 	}
 
 	if c.pprofPort > 0 {
 		go func() {
-			err = http.ListenAndServe(fmt.Sprintf(":%d", c.pprofPort), nil)
+			err := http.ListenAndServe(fmt.Sprintf(":%d", c.pprofPort), nil)
 
 			if err != nil {
 				slog.Error("pprof start failed", "error", err)
@@ -154,16 +188,18 @@ func (c *Component) Start() {
 	var lis net.Listener
 	lis, err = net.Listen("tcp", conf.Addr)
 	if err != nil {
-		panic(fmt.Errorf("tcp listen port failed: %w", err))
+		return fmt.Errorf("tcp listen port failed: %w", err)
 	}
 
 	slog.Info("Serving gRPC on " + conf.Addr)
+	c.ready.Store(true)
+	defer c.ready.Store(false)
 	err = c.grpcServer.Serve(lis)
 	if err != nil {
-		slog.Error("grpc server shutdown", "error", err)
-	} else {
-		slog.Info("grpc server shutdown")
+		return fmt.Errorf("grpc server shutdown: %w", err)
 	}
+	slog.Info("grpc server shutdown")
+	return
 }
 
 func (c *Component) detectAddr(oriAddr string) (realAddr string, err error) {
@@ -189,7 +225,7 @@ func (c *Component) detectAddr(oriAddr string) (realAddr string, err error) {
 	return
 }
 
-func registerService(config *registry.RegistryConfig, addr string, svr *grpc.Server) {
+func registerService(config *registry.RegistryConfig, addr string, svr *grpc.Server) (err error) {
 	if config == nil {
 		return
 	}
@@ -197,7 +233,7 @@ func registerService(config *registry.RegistryConfig, addr string, svr *grpc.Ser
 	config.Addr = addr
 	r, err := serviceregistry.New(config)
 	if err != nil {
-		panic(fmt.Errorf("create service registry failed: %w", err))
+		return fmt.Errorf("create service registry failed: %w", err)
 	}
 
 	for name := range svr.GetServiceInfo() {
@@ -206,16 +242,16 @@ func registerService(config *registry.RegistryConfig, addr string, svr *grpc.Ser
 			continue
 		}
 
-		err := r.Register(name)
+		err = r.Register(name)
 		if err != nil {
-			panic(fmt.Errorf("register service<%s> to registry failed: %w", name, err))
+			return fmt.Errorf("register service<%s> to registry failed: %w", name, err)
 		}
 	}
 
 	return
 }
 
-func traceAgent(conf *trace.TraceConfig, svr *grpc.Server, addr string) (providers map[string]*traceSdk.TracerProvider, deferFunc func()) {
+func traceAgent(conf *trace.TraceConfig, svr *grpc.Server, addr string) (providers map[string]*traceSdk.TracerProvider, deferFunc func(), err error) {
 	deferFunc = func() {}
 	if conf == nil {
 		return
@@ -228,12 +264,12 @@ func traceAgent(conf *trace.TraceConfig, svr *grpc.Server, addr string) (provide
 		if strings.Contains(name, "grpc") {
 			continue
 		}
-		var err error
 		conf.Name = name
 		conf.Addr = addr
 		providers[name], err = trace.NewTraceProvider(conf)
 		if err != nil {
-			panic(fmt.Errorf("create trace provider failed: %w", err))
+			err = fmt.Errorf("create trace provider failed: %w", err)
+			return
 		}
 	}
 
@@ -241,11 +277,39 @@ func traceAgent(conf *trace.TraceConfig, svr *grpc.Server, addr string) (provide
 		for _, tp := range providers {
 			tp.Shutdown(context.Background())
 		}
+	}, nil
+}
+
+// meterAgent 设置全局的指标上报, 一个进程只有一个 MeterProvider, 使用排序后的第一个服务名作为资源名
+func meterAgent(conf trace.TraceConfig, svr *grpc.Server, addr string) (deferFunc func(), err error) {
+	names := make([]string, 0, len(svr.GetServiceInfo()))
+	for name := range svr.GetServiceInfo() {
+		if strings.Contains(name, "grpc") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		conf.Name = names[0]
 	}
+	conf.Addr = addr
+	return trace.InitMeterProvider(&conf, time.Minute)
 }
 
 func (c *Component) Stop() {
-	c.grpcServer.Stop()
+	c.ready.Store(false)
+	if c.grpcServer != nil {
+		c.grpcServer.Stop()
+	}
+}
+
+// Ready grpc端口监听成功后就绪
+func (c *Component) Ready() error {
+	if !c.ready.Load() {
+		return errors.New("grpc server is not serving")
+	}
+	return nil
 }
 
 func RegisterGrpcService(registers ...func(grpc.ServiceRegistrar)) {
@@ -2,12 +2,15 @@ package grpcx
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
 
 	"github.com/goslacker/slacker/core/app"
 	"github.com/goslacker/slacker/core/grpcx"
 	"github.com/goslacker/slacker/core/registry"
-	"github.com/goslacker/slacker/core/trace"
-	"github.com/spf13/viper"
+	"google.golang.org/grpc/health"
 )
 
 func NewComponent() *Component {
@@ -16,41 +19,28 @@ func NewComponent() *Component {
 
 type Component struct {
 	app.Component
-	server *grpcx.Server
+	server atomic.Pointer[grpcx.Server]
 	cancel context.CancelFunc
 }
 
-func (c *Component) getConfig(conf *viper.Viper) (cfg config) {
-	cfg = config{
-		Addr:        conf.GetString("grpcx.addr"),
-		Network:     conf.GetString("grpcx.network"),
-		HealthCheck: conf.GetBool("grpcx.health_check"),
-		Reflection:  conf.GetBool("grpcx.reflection"),
-		PprofPort:   conf.GetInt("grpcx.pprof_port"),
-		Registry: grpcx.RegistryConfig{
-			Type:      conf.GetString("grpcx.registry.type"),
-			Endpoints: conf.GetStringSlice("grpcx.registry.endpoints"),
-		},
-		Trace: grpcx.TraceConfig{
-			Type:     trace.TraceType(conf.GetString("grpcx.trace.type")),
-			Endpoint: conf.GetString("grpcx.trace.endpoint"),
-		},
-	}
-	return
+func (c *Component) Name() string {
+	return "grpcx"
 }
 
 func (c *Component) Init() (err error) {
-	err = app.Bind[registry.Driver](func(conf *viper.Viper) (driver registry.Driver, err error) {
-		cfg := c.getConfig(conf)
+	cfg, err := app.Config[config]("grpcx")
+	if err != nil {
+		return
+	}
+
+	err = app.Bind[registry.Driver](func() (driver registry.Driver, err error) {
 		return registry.BuildDriver(cfg.Registry.Type, cfg.Registry.Endpoints)
 	})
 	if err != nil {
 		return
 	}
 
-	err = app.Bind[*grpcx.GrpcServerBuilder](func(conf *viper.Viper, driver registry.Driver) (server *grpcx.GrpcServerBuilder, err error) {
-		// conf.UnmarshalKey("grpcx", &config)有问题, 不能取到环境变量中的Network字段
-		cfg := c.getConfig(conf)
+	err = app.Bind[*grpcx.GrpcServerBuilder](func(driver registry.Driver) (server *grpcx.GrpcServerBuilder, err error) {
 		b := &grpcx.GrpcServerBuilder{
 			Addr:           cfg.Addr,
 			Network:        cfg.Network,
@@ -70,26 +60,57 @@ func (c *Component) Init() (err error) {
 	return
 }
 
+var _ app.Serviceable = (*Component)(nil)
+
+// Start 兼容旧的 Serviceable 接口, 调用 Serve 并记录错误
 func (c *Component) Start() {
-	builder := app.MustResolve[*grpcx.GrpcServerBuilder]()
-	server, err := builder.Build()
+	if err := c.Serve(); err != nil {
+		slog.Error("grpcx serve failed", "error", err)
+	}
+}
+
+// Serve 启动服务并阻塞, 构建或启动失败时返回错误
+func (c *Component) Serve() (err error) {
+	builder, err := app.Resolve[*grpcx.GrpcServerBuilder]()
 	if err != nil {
 		return
 	}
+	server, err := builder.Build()
+	if err != nil {
+		return fmt.Errorf("build grpc server failed: %w", err)
+	}
+	if hs := server.HealthCheckServer(); hs != nil {
+		err = app.Bind[*health.Server](hs)
+		if err != nil {
+			return fmt.Errorf("bind health check failed: %w", err)
+		}
+	}
 	ctx, cancel := context.WithCancel(context.Background())
-	c.server = server
+	c.server.Store(server)
 	c.cancel = cancel
-	server.Start(ctx)
+	return server.Start(ctx)
+}
+
+// Ready grpc端口监听成功后就绪
+func (c *Component) Ready() error {
+	server := c.server.Load()
+	if server == nil {
+		return errors.New("grpc server is not built")
+	}
+	return server.Ready()
 }
 
 func (c *Component) Stop() {
+	if server := c.server.Load(); server != nil {
+		server.Stop(context.Background())
+	}
 	if c.cancel != nil {
 		c.cancel()
 	}
 }
 
 type config struct {
-	Addr        string               `mapstructure:"addr"`
+	Addr        string               `mapstructure:"addr" validate:"required"`
 	Network     string               `mapstructure:"network"`
 	HealthCheck bool                 `mapstructure:"health_check"`
 	Reflection  bool                 `mapstructure:"reflection"`
@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/goslacker/slacker/core/app"
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type config struct {
+	Addr     string        `mapstructure:"addr" default:":8081"`
+	Interval time.Duration `mapstructure:"interval" default:"5s" validate:"gt=0"` // 同步 grpc 健康检查状态的间隔
+}
+
+func NewComponent() *Component {
+	return &Component{}
+}
+
+// Component 通过http暴露 /healthz 和 /readyz, 并把就绪状态同步到 grpcx 绑定的 grpc 健康检查服务
+type Component struct {
+	app.Component
+	svr    *http.Server
+	cancel context.CancelFunc
+	ready  atomic.Bool
+}
+
+func (c *Component) Name() string {
+	return "health"
+}
+
+var _ app.Serviceable = (*Component)(nil)
+
+// Start 兼容旧的 Serviceable 接口, 调用 Serve 并记录错误
+func (c *Component) Start() {
+	if err := c.Serve(); err != nil {
+		slog.Error("health serve failed", "error", err)
+	}
+}
+
+// Serve 启动服务并阻塞, 端口监听失败时返回错误
+func (c *Component) Serve() (err error) {
+	conf, err := app.Config[config]("health")
+	if err != nil {
+		return
+	}
+	addr := conf.Addr
+	c.svr = &http.Server{
+		Addr:    addr,
+		Handler: handler(),
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("health server listen failed: %w", err)
+	}
+
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	go c.syncGrpcHealth(ctx, conf.Interval)
+	slog.Info("Serving health check on " + addr)
+	c.ready.Store(true)
+	defer c.ready.Store(false)
+	err = c.svr.Serve(lis)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return
+}
+
+// Stop 停止服务并阻塞, 报错应打日志记录
+func (c *Component) Stop() {
+	c.ready.Store(false)
+	if c.cancel != nil {
+		c.cancel()
+	}
+	if c.svr == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = c.svr.Shutdown(ctx)
+}
+
+// Ready http端口监听成功后就绪
+func (c *Component) Ready() error {
+	if !c.ready.Load() {
+		return errors.New("health server is not serving")
+	}
+	return nil
+}
+
+// syncGrpcHealth 定时把 App 的就绪状态写入 grpc 健康检查服务, 未开启 grpc 健康检查时跳过
+func (c *Component) syncGrpcHealth(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if hs, err := app.Resolve[*health.Server](); err == nil {
+			if app.GetStatus().Ready {
+				hs.SetServingStatus("", healthgrpc.HealthCheckResponse_SERVING)
+			} else {
+				hs.SetServingStatus("", healthgrpc.HealthCheckResponse_NOT_SERVING)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handler /healthz 按存活状态, /readyz 按就绪状态返回 200 或 503, 响应体为组件状态
+func handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		status := app.GetStatus()
+		writeStatus(w, status.Healthy, status)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status := app.GetStatus()
+		writeStatus(w, status.Ready, status)
+	})
+	return mux
+}
+
+func writeStatus(w http.ResponseWriter, ok bool, status app.Status) {
+	w.Header().Set("Content-Type", "application/json")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(status)
+}
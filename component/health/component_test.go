@@ -0,0 +1,126 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goslacker/slacker/core/app"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// probeComponent 可以切换就绪和存活状态的服务组件
+type probeComponent struct {
+	app.Component
+	ready   atomic.Pointer[error]
+	healthy atomic.Pointer[error]
+	stop    chan struct{}
+}
+
+func newProbeComponent() *probeComponent {
+	return &probeComponent{stop: make(chan struct{})}
+}
+
+func errorOf(p *atomic.Pointer[error]) error {
+	if err := p.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func setError(p *atomic.Pointer[error], msg string) func() {
+	err := errors.New(msg)
+	p.Store(&err)
+	return func() { p.Store(nil) }
+}
+
+func (c *probeComponent) Name() string { return "probe" }
+
+func (c *probeComponent) Serve() error {
+	<-c.stop
+	return nil
+}
+
+func (c *probeComponent) Stop() { close(c.stop) }
+
+func (c *probeComponent) Ready() error {
+	return errorOf(&c.ready)
+}
+
+func (c *probeComponent) Healthy() error {
+	return errorOf(&c.healthy)
+}
+
+func TestHealth(t *testing.T) {
+	require.NoError(t, app.LoadConfig(app.WithContent("")))
+	probe := newProbeComponent()
+	app.RegisterComponent(probe)
+	_, err := app.Run()
+	require.NoError(t, err)
+	defer app.Shutdown()
+
+	h := handler()
+	get := func(path string) (int, app.Status) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var status app.Status
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		return w.Code, status
+	}
+
+	t.Run("ready and live", func(t *testing.T) {
+		code, status := get("/readyz")
+		require.Equal(t, http.StatusOK, code)
+		require.True(t, status.Ready)
+		code, _ = get("/healthz")
+		require.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("not ready", func(t *testing.T) {
+		defer setError(&probe.ready, "warming up")()
+
+		code, status := get("/readyz")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.Equal(t, []app.ComponentStatus{{Name: "probe", Healthy: true, Error: "warming up"}}, status.Components)
+		code, _ = get("/healthz")
+		require.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("not live", func(t *testing.T) {
+		defer setError(&probe.healthy, "deadlock")()
+
+		code, status := get("/healthz")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		require.False(t, status.Healthy)
+		require.Equal(t, "deadlock", status.Components[0].Error)
+	})
+
+	t.Run("sync grpc health", func(t *testing.T) {
+		hs := health.NewServer()
+		require.NoError(t, app.Bind[*health.Server](hs))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go NewComponent().syncGrpcHealth(ctx, 10*time.Millisecond)
+
+		serving := func() healthgrpc.HealthCheckResponse_ServingStatus {
+			resp, err := hs.Check(ctx, &healthgrpc.HealthCheckRequest{})
+			require.NoError(t, err)
+			return resp.Status
+		}
+		require.Eventually(t, func() bool {
+			return serving() == healthgrpc.HealthCheckResponse_SERVING
+		}, time.Second, 10*time.Millisecond)
+
+		defer setError(&probe.ready, "warming up")()
+		require.Eventually(t, func() bool {
+			return serving() == healthgrpc.HealthCheckResponse_NOT_SERVING
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -1,31 +1,89 @@
 package migrate
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 
 	"github.com/goslacker/slacker/core/app"
 	"github.com/goslacker/slacker/core/database"
+	"github.com/goslacker/slacker/core/lock"
+	"gorm.io/gorm"
 )
 
+// lockKey 多个实例同时启动时只有一个执行迁移, 锁由 gormx 绑定, 没有绑定时使用进程内的锁
+const lockKey = "slacker.migrate"
+
 func NewComponent() *Component {
 	m := &Component{}
 
 	return m
 }
 
+// config 迁移配置, 对应 migrate
+type config struct {
+	Auto   bool `mapstructure:"auto" default:"true"` // 启动时执行所有未执行的迁移
+	DryRun bool `mapstructure:"dry_run"`             // 只输出将要执行的 sql
+}
+
+// dbConfig 迁移的数据库与 gormx 的默认连接一致, 按其 DSN 的 scheme 选择驱动
+type dbConfig struct {
+	DSN app.Secret `mapstructure:"dsn" validate:"required"`
+}
+
 type Component struct {
 	app.Component
 }
 
+func (m Component) Name() string {
+	return "migrate"
+}
+
+// OptionalDependsOn 迁移使用 gormx 的锁
+func (m Component) OptionalDependsOn() []string {
+	return []string{"gormx"}
+}
+
 func (m Component) Init() (err error) {
-	err = app.Bind[database.Migrator](NewDefaultMigrator)
+	conf, err := app.Config[config]("migrate")
+	if err != nil {
+		return
+	}
+	dbConf, err := app.Config[dbConfig]("gormx")
 	if err != nil {
 		return
 	}
 
+	var opts []func(*DefaultMigrator)
+	if conf.DryRun {
+		opts = append(opts, WithDryRun(os.Stdout))
+	}
+	err = app.Bind[database.Migrator](func(db *sql.DB, gormDB *gorm.DB) database.Migrator {
+		return NewDefaultMigrator(db, database.DSN(dbConf.DSN.Reveal()), append(opts, WithGorm(gormDB))...)
+	})
+	if err != nil {
+		return
+	}
+
+	if !conf.Auto {
+		return
+	}
 	app.RegisterListener(func(event app.BeforeBoot) (err error) {
 		err = app.Invoke(func(m database.Migrator) (err error) {
+			locker, e := app.Resolve[lock.Locker]()
+			if e != nil {
+				locker = lock.NewMemoryLocker()
+			}
+			unlock, err := locker.Lock(context.Background(), lockKey)
+			if err != nil {
+				return
+			}
+			defer func() {
+				err = errors.Join(err, unlock())
+			}()
 			err = m.Migrate()
 			if err != nil {
 				return
@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"github.com/goslacker/slacker/core/app"
+	"github.com/goslacker/slacker/core/container"
+	"github.com/goslacker/slacker/core/database"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestComponent_WithoutLocker(t *testing.T) {
+	container.Set(container.NewContainer())
+	defer container.Set(nil)
+	require.NoError(t, app.LoadConfig(app.WithContent(`
+gormx:
+  dsn: sqlite://file:component?mode=memory&cache=shared
+`)))
+	// 不使用 gormx 组件, 自行绑定连接, 容器中没有 lock.Locker
+	gormDB, err := gorm.Open(sqlite.Open("file:component?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	db, err := gormDB.DB()
+	require.NoError(t, err)
+	require.NoError(t, app.Bind[*gorm.DB](gormDB))
+	require.NoError(t, app.Bind[*sql.DB](db))
+
+	require.NoError(t, NewComponent().Init())
+	m, err := app.Resolve[database.Migrator]()
+	require.NoError(t, err)
+	require.NoError(t, m.RegisterMigrates(fstest.MapFS{
+		"1_users.up.sql":   migrations["1_users.up.sql"],
+		"1_users.down.sql": migrations["1_users.down.sql"],
+	}))
+
+	require.NoError(t, app.Fire(app.BeforeBoot{}))
+	require.Equal(t, []uint{1}, applied(t, m))
+}
@@ -0,0 +1,185 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+)
+
+// DriverFunc 使用连接创建 golang-migrate 的数据库驱动, 驱动关闭时只关闭该连接
+type DriverFunc func(ctx context.Context, conn *sql.Conn) (database.Driver, error)
+
+var (
+	driversLock sync.RWMutex
+	drivers     = map[string]DriverFunc{
+		"mysql": func(ctx context.Context, conn *sql.Conn) (database.Driver, error) {
+			return mysql.WithConnection(ctx, conn, &mysql.Config{})
+		},
+		"postgres":   openPostgres,
+		"postgresql": openPostgres,
+		"sqlite":     newSqliteDriver,
+	}
+)
+
+func openPostgres(ctx context.Context, conn *sql.Conn) (database.Driver, error) {
+	return postgres.WithConnection(ctx, conn, &postgres.Config{})
+}
+
+// RegisterDriver 注册 DSN scheme 对应的迁移驱动, 已存在时覆盖
+func RegisterDriver(scheme string, f DriverFunc) {
+	driversLock.Lock()
+	defer driversLock.Unlock()
+	drivers[strings.ToLower(scheme)] = f
+}
+
+func driverOf(scheme string) (DriverFunc, error) {
+	driversLock.RLock()
+	defer driversLock.RUnlock()
+	f, ok := drivers[strings.ToLower(scheme)]
+	if !ok {
+		schemes := make([]string, 0, len(drivers))
+		for s := range drivers {
+			schemes = append(schemes, s)
+		}
+		sort.Strings(schemes)
+		return nil, fmt.Errorf("unsupported database <%s>, supported: %s", scheme, strings.Join(schemes, ", "))
+	}
+	return f, nil
+}
+
+const sqliteMigrationsTable = "schema_migrations"
+
+// sqliteDriver sqlite 的迁移驱动, golang-migrate 自带的 sqlite 驱动与 gormx 使用的驱动注册名冲突
+type sqliteDriver struct {
+	conn   *sql.Conn
+	locked atomic.Bool
+}
+
+func newSqliteDriver(ctx context.Context, conn *sql.Conn) (database.Driver, error) {
+	d := &sqliteDriver{conn: conn}
+	query := `CREATE TABLE IF NOT EXISTS ` + sqliteMigrationsTable + ` (version INTEGER NOT NULL, dirty BOOLEAN NOT NULL);
+CREATE UNIQUE INDEX IF NOT EXISTS version_unique ON ` + sqliteMigrationsTable + ` (version);`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return nil, &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	return d, nil
+}
+
+func (d *sqliteDriver) Open(url string) (database.Driver, error) {
+	return nil, errors.New("sqlite migrate driver can only be created with connection")
+}
+
+func (d *sqliteDriver) Close() error {
+	return d.conn.Close()
+}
+
+func (d *sqliteDriver) Lock() error {
+	if !d.locked.CompareAndSwap(false, true) {
+		return database.ErrLocked
+	}
+	return nil
+}
+
+func (d *sqliteDriver) Unlock() error {
+	if !d.locked.CompareAndSwap(true, false) {
+		return database.ErrNotLocked
+	}
+	return nil
+}
+
+// Run 在事务中执行迁移
+func (d *sqliteDriver) Run(migration io.Reader) (err error) {
+	query, err := io.ReadAll(migration)
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+	if _, err = tx.ExecContext(ctx, string(query)); err != nil {
+		_ = tx.Rollback()
+		return &database.Error{OrigErr: err, Err: "migration failed", Query: query}
+	}
+	if err = tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	return
+}
+
+func (d *sqliteDriver) SetVersion(version int, dirty bool) (err error) {
+	ctx := context.Background()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+	query := "DELETE FROM " + sqliteMigrationsTable
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		_ = tx.Rollback()
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	// 没有版本时也要记录 dirty 状态
+	if version >= 0 || (version == database.NilVersion && dirty) {
+		query = "INSERT INTO " + sqliteMigrationsTable + " (version, dirty) VALUES (?, ?)"
+		if _, err = tx.ExecContext(ctx, query, version, dirty); err != nil {
+			_ = tx.Rollback()
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+	return
+}
+
+func (d *sqliteDriver) Version() (version int, dirty bool, err error) {
+	query := "SELECT version, dirty FROM " + sqliteMigrationsTable + " LIMIT 1"
+	err = d.conn.QueryRowContext(context.Background(), query).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.NilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	return
+}
+
+func (d *sqliteDriver) Drop() (err error) {
+	ctx := context.Background()
+	query := `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`
+	rows, err := d.conn.QueryContext(ctx, query)
+	if err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			_ = rows.Close()
+			return
+		}
+		tables = append(tables, table)
+	}
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+	for _, table := range tables {
+		query = `DROP TABLE "` + strings.ReplaceAll(table, `"`, `""`) + `"`
+		if _, err = d.conn.ExecContext(ctx, query); err != nil {
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+	return
+}
@@ -1,6 +1,7 @@
 package migrate
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/fs"
@@ -18,6 +19,8 @@ func NewFsDriver() *EmbedDriver {
 	e := &EmbedDriver{
 		sorts: make([]uint, 0, 20),
 		files: make(map[string]file),
+
+		goMigrations: make(map[uint]GoMigration),
 	}
 	return e
 }
@@ -25,6 +28,8 @@ func NewFsDriver() *EmbedDriver {
 type EmbedDriver struct {
 	sorts []uint
 	files map[string]file
+
+	goMigrations map[uint]GoMigration
 }
 
 func (e *EmbedDriver) Add(f fs.FS) {
@@ -39,16 +44,10 @@ func (e *EmbedDriver) Add(f fs.FS) {
 			panic(fmt.Errorf("file name invalid: %w", err))
 		}
 		version := uint(tmp)
-		has := false
-		for _, v := range e.sorts {
-			if v == version {
-				has = true
-				break
-			}
-		}
-		if !has {
-			e.sorts = append(e.sorts, version)
+		if _, ok := e.goMigrations[version]; ok {
+			panic(fmt.Errorf("migration version %d already registered as go migration", version))
 		}
+		e.addVersion(version)
 
 		//取file
 		f := file{
@@ -58,6 +57,38 @@ func (e *EmbedDriver) Add(f fs.FS) {
 		key := fmt.Sprintf("%d_%s", version, strings.Split(entry.Name(), ".")[1])
 		e.files[key] = f
 	}
+}
+
+// AddGo 添加 Go 迁移, 版本不能与其他迁移重复
+func (e *EmbedDriver) AddGo(m GoMigration) error {
+	if m.Up == nil {
+		return fmt.Errorf("go migration version %d has no up function", m.Version)
+	}
+	if _, err := e.find(m.Version); err == nil {
+		return fmt.Errorf("migration version %d already exists", m.Version)
+	}
+	e.goMigrations[m.Version] = m
+	e.addVersion(m.Version)
+	e.files[fmt.Sprintf("%d_up", m.Version)] = file{
+		name: fmt.Sprintf("%d_%s.up.go", m.Version, m.Name),
+		body: []byte(fmt.Sprintf(goMarker, m.Version, "up")),
+	}
+	if m.Down != nil {
+		e.files[fmt.Sprintf("%d_down", m.Version)] = file{
+			name: fmt.Sprintf("%d_%s.down.go", m.Version, m.Name),
+			body: []byte(fmt.Sprintf(goMarker, m.Version, "down")),
+		}
+	}
+	return nil
+}
+
+func (e *EmbedDriver) addVersion(version uint) {
+	for _, v := range e.sorts {
+		if v == version {
+			return
+		}
+	}
+	e.sorts = append(e.sorts, version)
 	sort.Slice(e.sorts, func(i, j int) bool {
 		return e.sorts[i] < e.sorts[j]
 	})
@@ -130,11 +161,33 @@ func (e *EmbedDriver) ReadDown(version uint) (r io.ReadCloser, identifier string
 	return
 }
 
+// file 迁移文件, Go 迁移没有 fs, 内容为 body
 type file struct {
 	fs   fs.FS
 	name string
+	body []byte
 }
 
 func (f file) Open() (io.ReadCloser, error) {
+	if f.fs == nil {
+		return io.NopCloser(bytes.NewReader(f.body)), nil
+	}
 	return f.fs.Open(f.name)
 }
+
+// Versions 所有迁移版本, 从小到大排序
+func (e *EmbedDriver) Versions() []uint {
+	return append([]uint(nil), e.sorts...)
+}
+
+// Name 迁移的名称, 如 1_create_users.up.sql 的名称为 create_users
+func (e *EmbedDriver) Name(version uint) string {
+	for _, direction := range []string{"up", "down"} {
+		if f, ok := e.files[fmt.Sprintf("%d_%s", version, direction)]; ok {
+			_, name, _ := strings.Cut(f.name, "_")
+			name, _, _ = strings.Cut(name, ".")
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"gorm.io/gorm"
+)
+
+// goMarker Go 迁移在 source 中的内容, 由 goDriver 识别后执行对应的函数
+const goMarker = "-- go migration %d %s"
+
+// GoMigration 以 Go 函数实现的迁移, 与 sql 文件按版本统一排序, Up 和 Down 在事务中执行, Down 可以为空
+type GoMigration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// goDriver 包装数据库驱动, 执行 Go 迁移, 其他迁移交给原驱动
+type goDriver struct {
+	database.Driver
+	source *EmbedDriver
+	db     *gorm.DB
+}
+
+func (g *goDriver) Run(migration io.Reader) (err error) {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return
+	}
+	var (
+		version   uint
+		direction string
+	)
+	if !bytes.HasPrefix(body, []byte("-- go migration ")) {
+		return g.Driver.Run(bytes.NewReader(body))
+	}
+	if _, err = fmt.Sscanf(string(body), goMarker, &version, &direction); err != nil {
+		return fmt.Errorf("invalid go migration: %w", err)
+	}
+	m, ok := g.source.goMigrations[version]
+	if !ok {
+		return fmt.Errorf("go migration version %d not found", version)
+	}
+	if g.db == nil {
+		return errors.New("go migration requires gorm, see WithGorm")
+	}
+	f := m.Up
+	if direction == "down" {
+		f = m.Down
+	}
+	if err = g.db.Transaction(f); err != nil {
+		return &database.Error{OrigErr: err, Err: "migration failed", Query: body}
+	}
+	return
+}
@@ -1,70 +1,304 @@
-//go:build !sqlite
-
 package migrate
 
 import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"os"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/goslacker/slacker/core/database"
+	"gorm.io/gorm"
 )
 
-func NewDefaultMigrator(db *sql.DB) *DefaultMigrator {
-	return &DefaultMigrator{
+// WithDryRun 只把将要执行的 sql 输出到 w, 不执行迁移
+func WithDryRun(w io.Writer) func(*DefaultMigrator) {
+	return func(d *DefaultMigrator) {
+		d.dryRun = w
+	}
+}
+
+// WithGorm Go 迁移使用的数据库连接
+func WithGorm(db *gorm.DB) func(*DefaultMigrator) {
+	return func(d *DefaultMigrator) {
+		d.gorm = db
+	}
+}
+
+// NewDefaultMigrator 按 dsn 的 scheme 选择迁移驱动, 见 RegisterDriver
+func NewDefaultMigrator(db *sql.DB, dsn database.DSN, opts ...func(*DefaultMigrator)) *DefaultMigrator {
+	d := &DefaultMigrator{
 		db:             db,
+		dsn:            dsn,
 		SourceInstance: NewFsDriver(),
 	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
+var _ database.Migrator = (*DefaultMigrator)(nil)
+
 type DefaultMigrator struct {
 	SourceInstance *EmbedDriver
 	db             *sql.DB
+	dsn            database.DSN
+	dryRun         io.Writer
+	gorm           *gorm.DB
+}
+
+// RegisterMigrates 注册迁移, 支持 sql 文件所在的 fs.FS 和 GoMigration
+func (d *DefaultMigrator) RegisterMigrates(ms ...any) (err error) {
+	for _, m := range ms {
+		switch m := m.(type) {
+		case fs.FS:
+			d.SourceInstance.Add(m)
+		case GoMigration:
+			err = d.SourceInstance.AddGo(m)
+		case *GoMigration:
+			err = d.SourceInstance.AddGo(*m)
+		default:
+			err = fmt.Errorf("unsupported migration type %T", m)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (d *DefaultMigrator) Migrate() (err error) {
+	return d.Up(0)
 }
 
 func (d *DefaultMigrator) Up(stepNum int) error {
-	//TODO implement me
-	panic("implement me")
+	return d.run(func(m *migrate.Migrate) error {
+		if stepNum <= 0 {
+			return m.Up()
+		}
+		return m.Steps(stepNum)
+	}, func(current int) ([]step, error) {
+		return d.planUp(current, stepNum, -1)
+	})
 }
 
 func (d *DefaultMigrator) Down(stepNum int) error {
-	//TODO implement me
-	panic("implement me")
+	if stepNum <= 0 {
+		return fmt.Errorf("down step num must be positive, got %d, use DownAll to roll back all migrations", stepNum)
+	}
+	return d.run(func(m *migrate.Migrate) error {
+		return m.Steps(-stepNum)
+	}, func(current int) ([]step, error) {
+		return d.planDown(current, stepNum, -1)
+	})
 }
 
-func (d *DefaultMigrator) RegisterMigrates(ms ...any) (err error) {
-	for _, m := range ms {
-		d.SourceInstance.Add(m.(fs.FS))
+func (d *DefaultMigrator) DownAll() error {
+	return d.run(func(m *migrate.Migrate) error {
+		return m.Down()
+	}, func(current int) ([]step, error) {
+		return d.planDown(current, 0, -1)
+	})
+}
+
+func (d *DefaultMigrator) Goto(version uint) error {
+	return d.run(func(m *migrate.Migrate) error {
+		return m.Migrate(version)
+	}, func(current int) ([]step, error) {
+		if _, err := d.SourceInstance.find(version); err != nil {
+			return nil, fmt.Errorf("migration version %d not found", version)
+		}
+		if int(version) >= current {
+			return d.planUp(current, 0, int(version))
+		}
+		return d.planDown(current, 0, int(version))
+	})
+}
+
+// Force 不执行迁移, dry-run 时只输出将要设置的版本
+func (d *DefaultMigrator) Force(version int) error {
+	if d.dryRun != nil {
+		_, err := fmt.Fprintf(d.dryRun, "-- force version %d\n", version)
+		return err
 	}
+	return d.withMigrate(func(m *migrate.Migrate) error {
+		return m.Force(version)
+	})
+}
 
+func (d *DefaultMigrator) Status() (status []database.MigrationStatus, err error) {
+	err = d.withMigrate(func(m *migrate.Migrate) error {
+		current, dirty, err := version(m)
+		if err != nil {
+			return err
+		}
+		for _, v := range d.SourceInstance.Versions() {
+			status = append(status, database.MigrationStatus{
+				Version: v,
+				Name:    d.SourceInstance.Name(v),
+				Applied: current >= 0 && v <= uint(current),
+				Dirty:   dirty && int(v) == current,
+			})
+		}
+		return nil
+	})
 	return
 }
 
-func (d *DefaultMigrator) Migrate() (err error) {
-	var m *migrate.Migrate
+// version 当前版本, 没有执行过迁移时为 -1
+func version(m *migrate.Migrate) (current int, dirty bool, err error) {
+	v, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return -1, false, nil
+	}
+	return int(v), dirty, err
+}
 
+// run 执行迁移, dry-run 时按 plan 输出将要执行的 sql
+func (d *DefaultMigrator) run(f func(m *migrate.Migrate) error, plan func(current int) ([]step, error)) error {
+	if d.dryRun == nil {
+		return d.withMigrate(f)
+	}
+	return d.withMigrate(func(m *migrate.Migrate) error {
+		current, dirty, err := version(m)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return migrate.ErrDirty{Version: current}
+		}
+		steps, err := plan(current)
+		if err != nil {
+			return err
+		}
+		for _, s := range steps {
+			_, err = fmt.Fprintf(d.dryRun, "-- %s\n%s\n", s.identifier, strings.TrimSpace(string(s.query)))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (d *DefaultMigrator) withMigrate(f func(m *migrate.Migrate) error) (err error) {
+	scheme, _, ok := strings.Cut(string(d.dsn), "://")
+	if !ok || scheme == "" {
+		return errors.New("dsn is invalid, forget schema?")
+	}
+	newDriver, err := driverOf(scheme)
+	if err != nil {
+		return
+	}
 	ctx := context.Background()
-	newConn, err := d.db.Conn(ctx)
+	conn, err := d.db.Conn(ctx)
 	if err != nil {
 		return
 	}
-	dbInstance, err := mysql.WithConnection(ctx, newConn, &mysql.Config{})
+	dbInstance, err := newDriver(ctx, conn)
 	if err != nil {
+		_ = conn.Close()
 		return
 	}
-	m, err = migrate.NewWithInstance("", d.SourceInstance, "", dbInstance)
+	dbInstance = &goDriver{Driver: dbInstance, source: d.SourceInstance, db: d.gorm}
+	m, err := migrate.NewWithInstance("embed", d.SourceInstance, scheme, dbInstance)
 	if err != nil {
+		_ = dbInstance.Close()
 		return
 	}
+	// 只关闭借用的连接, 不会关闭 db
 	defer func() {
 		_, _ = m.Close()
 	}()
 
-	err = m.Up()
-	if errors.Is(err, migrate.ErrNoChange) {
+	err = f(m)
+	var short migrate.ErrShortLimit
+	if errors.Is(err, migrate.ErrNoChange) || errors.As(err, &short) {
 		err = nil
 	}
 	return
 }
+
+// step dry-run 时一个将要执行的迁移
+type step struct {
+	identifier string
+	query      []byte
+}
+
+func (d *DefaultMigrator) read(version uint, up bool) (s step, err error) {
+	var r io.ReadCloser
+	if up {
+		r, s.identifier, err = d.SourceInstance.ReadUp(version)
+	} else {
+		r, s.identifier, err = d.SourceInstance.ReadDown(version)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		// 没有对应的文件时只变更版本
+		return step{identifier: fmt.Sprintf("version %d has no migration file", version)}, nil
+	}
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	s.query, err = io.ReadAll(r)
+	return
+}
+
+// planUp 从 current 开始向上, 最多 limit 个, to 不小于 0 时到该版本为止
+func (d *DefaultMigrator) planUp(current int, limit int, to int) (steps []step, err error) {
+	for limit <= 0 || len(steps) < limit {
+		if to >= 0 && current == to {
+			return
+		}
+		var next uint
+		if current < 0 {
+			next, err = d.SourceInstance.First()
+		} else {
+			next, err = d.SourceInstance.Next(uint(current))
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			return steps, nil
+		}
+		if err != nil {
+			return
+		}
+		var s step
+		s, err = d.read(next, true)
+		if err != nil {
+			return
+		}
+		steps = append(steps, s)
+		current = int(next)
+	}
+	return
+}
+
+// planDown 从 current 开始回滚, 最多 limit 个, to 不小于 0 时回滚到该版本为止
+func (d *DefaultMigrator) planDown(current int, limit int, to int) (steps []step, err error) {
+	for current >= 0 && (limit <= 0 || len(steps) < limit) {
+		if to >= 0 && current == to {
+			return
+		}
+		var s step
+		s, err = d.read(uint(current), false)
+		if err != nil {
+			return
+		}
+		steps = append(steps, s)
+		prev, err := d.SourceInstance.Prev(uint(current))
+		if errors.Is(err, os.ErrNotExist) {
+			return steps, nil
+		}
+		if err != nil {
+			return steps, err
+		}
+		current = int(prev)
+	}
+	return
+}
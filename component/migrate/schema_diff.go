@@ -0,0 +1,171 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// Diff 比较模型和数据库当前的表结构, 返回创建缺少的表, 列和索引的语句及对应的回滚语句,
+// 不处理列类型变更和多余的表, 列, 索引
+func Diff(db *gorm.DB, models ...any) (up []string, down []string, err error) {
+	rec := &recorder{}
+	dry := db.Session(&gorm.Session{DryRun: true, Logger: rec, NewDB: true})
+	migrator := db.Migrator()
+
+	// record 执行 f 并返回其生成的语句
+	record := func(f func(tx *gorm.DB) error) ([]string, error) {
+		rec.sqls = nil
+		if err := f(dry); err != nil {
+			return nil, err
+		}
+		return rec.sqls, nil
+	}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err = stmt.Parse(model); err != nil {
+			return nil, nil, fmt.Errorf("parse model %T failed: %w", model, err)
+		}
+		var sqls, reverts []string
+		if !migrator.HasTable(model) {
+			sqls, err = record(func(tx *gorm.DB) error {
+				return tx.Migrator().CreateTable(model)
+			})
+			if err != nil {
+				return
+			}
+			up = append(up, sqls...)
+			reverts, err = record(func(tx *gorm.DB) error {
+				return tx.Exec("DROP TABLE IF EXISTS ?", clause.Table{Name: stmt.Table}).Error
+			})
+			if err != nil {
+				return
+			}
+			down = append(reverts, down...)
+			continue
+		}
+
+		for _, dbName := range stmt.Schema.DBNames {
+			field := stmt.Schema.FieldsByDBName[dbName]
+			if field.IgnoreMigration || migrator.HasColumn(model, dbName) {
+				continue
+			}
+			sqls, err = record(func(tx *gorm.DB) error {
+				return tx.Migrator().AddColumn(model, field.Name)
+			})
+			if err != nil {
+				return
+			}
+			up = append(up, sqls...)
+			reverts, err = record(func(tx *gorm.DB) error {
+				return tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: stmt.Table}, clause.Column{Name: dbName}).Error
+			})
+			if err != nil {
+				return
+			}
+			down = append(reverts, down...)
+		}
+
+		indexes := stmt.Schema.ParseIndexes()
+		names := make([]string, 0, len(indexes))
+		for name := range indexes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if migrator.HasIndex(model, name) {
+				continue
+			}
+			sqls, err = record(func(tx *gorm.DB) error {
+				return tx.Migrator().CreateIndex(model, name)
+			})
+			if err != nil {
+				return
+			}
+			up = append(up, sqls...)
+			reverts, err = record(func(tx *gorm.DB) error {
+				return tx.Migrator().DropIndex(model, name)
+			})
+			if err != nil {
+				return
+			}
+			down = append(reverts, down...)
+		}
+	}
+	return
+}
+
+// Generate 把 Diff 的结果写入 dir 中下一个版本的 .up.sql 和 .down.sql, 没有差异时不生成文件
+func Generate(db *gorm.DB, dir string, name string, models ...any) (files []string, err error) {
+	up, down, err := Diff(db, models...)
+	if err != nil || len(up) == 0 {
+		return
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+	version, err := nextVersion(dir)
+	if err != nil {
+		return
+	}
+	for direction, sqls := range map[string][]string{"up": up, "down": down} {
+		path := filepath.Join(dir, fmt.Sprintf("%d_%s.%s.sql", version, name, direction))
+		if err = os.WriteFile(path, []byte(strings.Join(sqls, ";\n")+";\n"), 0o644); err != nil {
+			return
+		}
+		files = append(files, path)
+	}
+	slices.Sort(files)
+	return
+}
+
+// nextVersion dir 中最大的迁移版本加一
+func nextVersion(dir string) (version uint, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		version = max(version, uint(v))
+	}
+	return version + 1, nil
+}
+
+// recorder 记录 dry-run 时生成的语句
+type recorder struct {
+	sqls []string
+}
+
+func (r *recorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *recorder) Info(context.Context, string, ...any) {}
+
+func (r *recorder) Warn(context.Context, string, ...any) {}
+
+func (r *recorder) Error(context.Context, string, ...any) {}
+
+func (r *recorder) Trace(_ context.Context, _ time.Time, fc func() (sql string, rowsAffected int64), _ error) {
+	sql, _ := fc()
+	r.sqls = append(r.sqls, sql)
+}
@@ -0,0 +1,90 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type account struct {
+	ID   uint64
+	Name string `gorm:"size:64"`
+}
+
+type accountV2 struct {
+	ID    uint64
+	Name  string `gorm:"size:64"`
+	Email string `gorm:"size:128;index:idx_accounts_email"`
+	Skip  string `gorm:"-:migration"`
+}
+
+func (accountV2) TableName() string {
+	return "accounts"
+}
+
+type profile struct {
+	ID     uint64
+	Bio    string
+	UserID uint64 `gorm:"index"`
+}
+
+func TestDiff(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:diff?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&account{}))
+
+	t.Run("no change", func(t *testing.T) {
+		up, down, err := Diff(db, &account{})
+		require.NoError(t, err)
+		require.Empty(t, up)
+		require.Empty(t, down)
+	})
+
+	t.Run("missing table column and index", func(t *testing.T) {
+		up, down, err := Diff(db, &accountV2{}, &profile{})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"ALTER TABLE `accounts` ADD `email` text",
+			"CREATE INDEX `idx_accounts_email` ON `accounts`(`email`)",
+			"CREATE TABLE `profiles` (`id` integer PRIMARY KEY AUTOINCREMENT,`bio` text,`user_id` integer)",
+			"CREATE INDEX `idx_profiles_user_id` ON `profiles`(`user_id`)",
+		}, up)
+		require.Equal(t, []string{
+			"DROP TABLE IF EXISTS `profiles`",
+			"DROP INDEX `idx_accounts_email`",
+			"ALTER TABLE `accounts` DROP COLUMN `email`",
+		}, down)
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "3_init.up.sql"), nil, 0o644))
+
+		files, err := Generate(db, dir, "add_profiles", &accountV2{}, &profile{})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			filepath.Join(dir, "4_add_profiles.down.sql"),
+			filepath.Join(dir, "4_add_profiles.up.sql"),
+		}, files)
+
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		m := NewDefaultMigrator(sqlDB, "sqlite://file:diff?mode=memory&cache=shared")
+		require.NoError(t, m.RegisterMigrates(os.DirFS(dir)))
+		require.NoError(t, m.Migrate())
+
+		files, err = Generate(db, dir, "nothing", &accountV2{}, &profile{})
+		require.NoError(t, err)
+		require.Empty(t, files)
+
+		require.NoError(t, m.Down(1))
+		up, _, err := Diff(db, &accountV2{}, &profile{})
+		require.NoError(t, err)
+		require.Len(t, up, 4)
+	})
+}
@@ -0,0 +1,133 @@
+package mqx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/goslacker/slacker/component/worker"
+	"github.com/goslacker/slacker/core/app"
+	"github.com/goslacker/slacker/core/mq"
+	"github.com/goslacker/slacker/core/mq/redismq"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisConfig struct {
+	Addrs     []string      `mapstructure:"addrs"`
+	Username  string        `mapstructure:"username"`
+	Password  app.Secret    `mapstructure:"password"`
+	DB        int           `mapstructure:"db"`
+	MaxLen    int64         `mapstructure:"max_len"`                                  // 每个 stream 保留的最大消息数, 为0时不裁剪
+	ClaimIdle time.Duration `mapstructure:"claim_idle" default:"30s" validate:"gt=0"` // 超过该时间未确认的消息会被重新投递
+}
+
+// config mqx 配置
+type config struct {
+	Driver string      `mapstructure:"driver" default:"memory" validate:"oneof=memory redis"`
+	Redis  redisConfig `mapstructure:"redis"`
+}
+
+type consumer struct {
+	topic   string
+	group   string
+	handler mq.Handler
+	opts    []func(*mq.SubscribeOpts)
+}
+
+var (
+	consumersLock sync.Mutex
+	consumers     []consumer
+)
+
+// Subscribe 注册消费者, 组件 Boot 时作为 worker 注册到 worker.Manager, 应在 Boot 之前调用, 如其他组件的 Init 中
+func Subscribe(topic string, group string, handler mq.Handler, opts ...func(*mq.SubscribeOpts)) {
+	consumersLock.Lock()
+	defer consumersLock.Unlock()
+	consumers = append(consumers, consumer{topic: topic, group: group, handler: handler, opts: opts})
+}
+
+func NewComponent() *Component {
+	return &Component{}
+}
+
+// Component 绑定 *mq.Broker 和 mq.Publisher, 并把注册的消费者作为 worker 运行, 需要同时使用 worker 组件
+type Component struct {
+	app.Component
+}
+
+func (c *Component) Name() string {
+	return "mqx"
+}
+
+// OptionalDependsOn 消费者会访问数据库和调用 grpc 服务
+func (c *Component) OptionalDependsOn() []string {
+	return []string{"gormx", "grpcx"}
+}
+
+func (c *Component) Init() (err error) {
+	conf, err := app.Config[config]("mqx")
+	if err != nil {
+		return
+	}
+	driver, err := newDriver(conf)
+	if err != nil {
+		return
+	}
+
+	err = app.Bind[*mq.Broker](mq.NewBroker(driver))
+	if err != nil {
+		return
+	}
+	return app.Bind[mq.Publisher](func(broker *mq.Broker) mq.Publisher {
+		return broker
+	})
+}
+
+func newDriver(conf config) (mq.Driver, error) {
+	switch conf.Driver {
+	case "redis":
+		if len(conf.Redis.Addrs) == 0 {
+			return nil, errors.New("mqx.redis.addrs is required when driver is redis")
+		}
+		client := redis.NewUniversalClient(&redis.UniversalOptions{
+			Addrs:    conf.Redis.Addrs,
+			Username: conf.Redis.Username,
+			Password: conf.Redis.Password.Reveal(),
+			DB:       conf.Redis.DB,
+		})
+		return redismq.New(client, redismq.WithMaxLen(conf.Redis.MaxLen), redismq.WithClaimIdle(conf.Redis.ClaimIdle)), nil
+	default:
+		return mq.NewMemoryDriver(), nil
+	}
+}
+
+// Boot 把注册的消费者作为 worker 注册到 worker.Manager, 异常退出时由 worker.Manager 重新启动
+func (c *Component) Boot() (err error) {
+	consumersLock.Lock()
+	registered := consumers
+	consumersLock.Unlock()
+	if len(registered) == 0 {
+		return
+	}
+
+	broker, err := app.Resolve[*mq.Broker]()
+	if err != nil {
+		return
+	}
+	manager, err := app.Resolve[*worker.Manager]()
+	if err != nil {
+		return fmt.Errorf("mqx consumers require worker component: %w", err)
+	}
+	for _, item := range registered {
+		manager.Register(func(ctx context.Context) {
+			err := broker.Subscribe(ctx, item.topic, item.group, item.handler, item.opts...)
+			if err != nil {
+				slog.Error("consumer stopped", "topic", item.topic, "group", item.group, "error", err)
+			}
+		}, worker.WithName("mq:"+item.topic+":"+item.group))
+	}
+	return
+}
@@ -3,7 +3,6 @@ package registry
 import (
 	"github.com/goslacker/slacker/core/app"
 	"github.com/goslacker/slacker/core/registry"
-	"github.com/spf13/viper"
 )
 
 type RegistryConfig struct {
@@ -17,17 +16,22 @@ type Component struct {
 	app.Component
 }
 
-func (c *Component) getConfig(conf *viper.Viper) (cfg RegistryConfig) {
-	cfg = RegistryConfig{
-		Type:      conf.GetString("grpcx.registry.type"),
-		Endpoints: conf.GetStringSlice("grpcx.registry.endpoints"),
-	}
-	return
+func (c *Component) Name() string {
+	return "registry"
+}
+
+// OptionalDependsOn 注册 grpcx 的服务地址, 在服务启动后注册, 停止前注销
+func (c *Component) OptionalDependsOn() []string {
+	return []string{"grpcx"}
 }
 
 func (c *Component) Init() (err error) {
-	err = app.Bind[*registry.Driver](func(conf *viper.Viper) (driver registry.Driver, err error) {
-		cfg := c.getConfig(conf)
+	cfg, err := app.Config[RegistryConfig]("grpcx.registry")
+	if err != nil {
+		return
+	}
+
+	err = app.Bind[*registry.Driver](func() (driver registry.Driver, err error) {
 		return registry.BuildDriver(cfg.Type, cfg.Endpoints)
 	})
 
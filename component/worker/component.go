@@ -17,6 +17,15 @@ type Component struct {
 	cancel context.CancelFunc
 }
 
+func (c *Component) Name() string {
+	return "worker"
+}
+
+// OptionalDependsOn worker 会访问数据库, 调用 grpc 服务和消费消息, 在这些组件之前停止
+func (c *Component) OptionalDependsOn() []string {
+	return []string{"gormx", "grpcx", "mqx"}
+}
+
 func (c *Component) Init() error {
 	return app.Bind[*Manager](func() *Manager {
 		return NewManager()
@@ -0,0 +1,55 @@
+package worker_test
+
+// 使用外部测试包, 内置组件 gormx, mqx 依赖 worker
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/goslacker/slacker/component/gormx"
+	"github.com/goslacker/slacker/component/grpcgatewayx"
+	"github.com/goslacker/slacker/component/grpcx"
+	"github.com/goslacker/slacker/component/mqx"
+	"github.com/goslacker/slacker/component/registry"
+	"github.com/goslacker/slacker/component/worker"
+	"github.com/goslacker/slacker/core/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinDependencies(t *testing.T) {
+	t.Run("registered out of order", func(t *testing.T) {
+		a := app.NewApp()
+		a.RegisterComponent(
+			worker.NewComponent(),
+			grpcgatewayx.NewComponent(),
+			registry.NewComponent(),
+			mqx.NewComponent(),
+			gormx.NewSeedComponent(),
+			grpcx.NewComponent(),
+			gormx.NewComponent(),
+		)
+		names, err := a.Components()
+		require.NoError(t, err)
+		// 按此顺序启动, 逆序停止
+		before := func(dep, component string) {
+			require.Less(t, slices.Index(names, dep), slices.Index(names, component), "%s should start before %s", dep, component)
+		}
+		before("grpcx", "grpcgatewayx")
+		before("grpcx", "registry")
+		before("gormx", "gormx.seed")
+		for _, dep := range []string{"gormx", "grpcx"} {
+			before(dep, "mqx")
+		}
+		for _, dep := range []string{"gormx", "grpcx", "mqx"} {
+			before(dep, "worker")
+		}
+	})
+
+	t.Run("missing optional dependency", func(t *testing.T) {
+		a := app.NewApp()
+		a.RegisterComponent(worker.NewComponent(), grpcx.NewComponent())
+		names, err := a.Components()
+		require.NoError(t, err)
+		require.Equal(t, []string{"grpcx", "worker"}, names)
+	})
+}
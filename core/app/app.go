@@ -8,31 +8,96 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
+
+	"github.com/goslacker/slacker/core/container"
+	"github.com/goslacker/slacker/core/eventbus"
 )
 
-func NewApp() *App {
-	return &App{
+// WithTimeout 设置组件在某个阶段的默认超时时间, 为0时不限制
+func WithTimeout(phase Phase, timeout time.Duration) func(*App) {
+	return func(a *App) {
+		a.timeouts[phase] = timeout
+	}
+}
+
+func NewApp(opts ...func(*App)) *App {
+	a := &App{
 		components: make([]Component, 0, 20),
+		deps:       make(map[string][]string),
+		timeouts:   make(map[Phase]time.Duration, len(defaultTimeouts)),
+		failed:     make(chan error, 1),
 	}
+	for phase, timeout := range defaultTimeouts {
+		a.timeouts[phase] = timeout
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
 }
 
 type App struct {
 	components []Component
+	deps       map[string][]string
+	timeouts   map[Phase]time.Duration
+	sorted     []*node
+	started    []*node
+	running    bool
+	stopping   bool
+	failed     chan error
+	lock       sync.RWMutex
 	wg         sync.WaitGroup
 }
 
 func (a *App) RegisterComponent(components ...Component) {
 	a.components = append(a.components, components...)
+	a.sorted = nil
+}
+
+// DependsOn 为组件声明依赖, 用于给无法修改代码的组件补充依赖关系
+func (a *App) DependsOn(component string, dependencies ...string) {
+	a.deps[component] = append(a.deps[component], dependencies...)
+	a.sorted = nil
+}
+
+// SetTimeout 设置组件在某个阶段的默认超时时间, 为0时不限制
+func (a *App) SetTimeout(phase Phase, timeout time.Duration) {
+	a.timeouts[phase] = timeout
+}
+
+// Components 按依赖关系排序后的组件名称
+func (a *App) Components() (names []string, err error) {
+	err = a.sort()
+	if err != nil {
+		return
+	}
+	for _, n := range a.sorted {
+		names = append(names, n.name)
+	}
+	return
+}
+
+func (a *App) sort() (err error) {
+	if a.sorted != nil {
+		return
+	}
+	a.sorted, err = sortComponents(a.components, a.deps)
+	return
 }
 
 func (a *App) Init() (err error) {
+	err = a.sort()
+	if err != nil {
+		return
+	}
 	err = Fire(BeforeInit{})
 	if err != nil {
 		return
 	}
-	for _, module := range a.components {
-		if m, ok := module.(Initable); ok {
-			err = m.Init()
+	for _, n := range a.sorted {
+		if m, ok := n.component.(Initable); ok {
+			err = runPhase(n, PhaseInit, a.timeouts[PhaseInit], m.Init)
 			if err != nil {
 				return
 			}
@@ -47,13 +112,17 @@ func (a *App) Init() (err error) {
 }
 
 func (a *App) Boot() (err error) {
+	err = a.sort()
+	if err != nil {
+		return
+	}
 	err = Fire(BeforeBoot{})
 	if err != nil {
 		return
 	}
-	for _, module := range a.components {
-		if m, ok := module.(Bootable); ok {
-			err = m.Boot()
+	for _, n := range a.sorted {
+		if m, ok := n.component.(Bootable); ok {
+			err = runPhase(n, PhaseBoot, a.timeouts[PhaseBoot], m.Boot)
 			if err != nil {
 				return
 			}
@@ -78,18 +147,45 @@ func (a *App) Run() (n int, err error) {
 		return
 	}
 
+	// 组件都已完成绑定, 启动前检查依赖是否完整
+	err = container.Default().Validate()
+	if err != nil {
+		err = fmt.Errorf("validate container failed: %w", err)
+		return
+	}
+
 	err = Fire(BeforeRun{})
 	if err != nil {
 		return
 	}
-	for _, m := range a.components {
-		if module, ok := m.(Serviceable); ok {
-			a.wg.Add(1)
-			go func(start func()) {
-				defer a.wg.Done()
-				start()
-			}(module.Start)
-			n++
+	a.lock.Lock()
+	a.stopping = false
+	a.lock.Unlock()
+	for _, nd := range a.sorted {
+		serve, stop, ok := service(nd.component)
+		if !ok {
+			continue
+		}
+		nd.done = make(chan struct{})
+		nd.stop = stop
+		a.wg.Add(1)
+		go func(nd *node, serve func() error) {
+			defer a.wg.Done()
+			defer close(nd.done)
+			nd.err = serve()
+			a.fail(nd)
+		}(nd, serve)
+		a.lock.Lock()
+		a.started = append(a.started, nd)
+		a.lock.Unlock()
+		n++
+
+		if r, ok := nd.component.(Readiness); ok {
+			err = waitReady(nd, r, a.timeouts[PhaseStart])
+			if err != nil {
+				a.Shutdown()
+				return
+			}
 		}
 	}
 	err = Fire(AfterRun{})
@@ -98,28 +194,93 @@ func (a *App) Run() (n int, err error) {
 		return
 	}
 
+	a.lock.Lock()
+	a.running = true
+	a.lock.Unlock()
+
 	return
 }
 
-func (a *App) Shutdown() {
-	err := Fire(BeforeShutdown{})
-	if err != nil {
-		slog.Error("fire before shutdown event failed", "error", err)
+// Shutdown 按启动的逆序停止组件, 每个组件需在超时时间内停止并退出 Start, 然后关闭容器中的实例, 返回所有失败的错误
+func (a *App) Shutdown() (err error) {
+	var errs []error
+	a.lock.Lock()
+	a.running = false
+	a.stopping = true
+	started := a.started
+	a.started = nil
+	a.lock.Unlock()
+
+	e := Fire(BeforeShutdown{})
+	if e != nil {
+		slog.Error("fire before shutdown event failed", "error", e)
 	}
-	for _, m := range a.components {
-		if module, ok := m.(Serviceable); ok {
-			go module.Stop()
+	println("wait module stop...")
+	for i := len(started) - 1; i >= 0; i-- {
+		nd := started[i]
+		select {
+		case <-nd.done:
+			// 已经退出的服务不需要再停止
+			continue
+		default:
+		}
+		e = runPhase(nd, PhaseStop, a.timeouts[PhaseStop], recoverServe(func() error {
+			nd.stop()
+			<-nd.done
+			return nil
+		}))
+		if e != nil {
+			slog.Error("stop component failed", "component", nd.name, "error", e)
+			errs = append(errs, e)
 		}
 	}
-	println("wait module stop...")
-	a.wg.Wait()
+	if len(errs) == 0 {
+		a.wg.Wait()
+	}
+	// 等待异步监听器处理完已发出的事件
+	eventbus.Default().Wait()
+	// 组件都已停止, 按创建的逆序关闭容器中的实例, 如数据库连接, etcd 客户端
+	e = container.Default().Close()
+	if e != nil {
+		slog.Error("close container failed", "error", e)
+		errs = append(errs, e)
+	}
 	println("bye bye~~")
-	err = Fire(AfterShutdown{})
-	if err != nil {
-		slog.Error("fire after shutdown event failed", "error", err)
+	e = Fire(AfterShutdown{})
+	if e != nil {
+		slog.Error("fire after shutdown event failed", "error", e)
+	}
+	// 停止异步监听器的 worker
+	eventbus.Default().Close()
+	return errors.Join(errs...)
+}
+
+// fail 服务组件在 Shutdown 之前退出时, 记录第一个失败的组件供 RunAndWait 停止 App
+func (a *App) fail(nd *node) {
+	a.lock.RLock()
+	stopping := a.stopping
+	a.lock.RUnlock()
+	if stopping {
+		if nd.err != nil {
+			slog.Error("service exited with error while stopping", "component", nd.name, "error", nd.err)
+		}
+		return
+	}
+
+	err := nd.err
+	if err == nil {
+		slog.Warn("service exited", "component", nd.name)
+		return
+	}
+	err = &ComponentError{Component: nd.name, Phase: PhaseStart, Err: err}
+	slog.Error("service failed", "component", nd.name, "error", err)
+	select {
+	case a.failed <- err:
+	default:
 	}
 }
 
+// RunAndWait 启动 App 并阻塞, 收到退出信号或任一服务组件启动失败时按序停止已启动的组件
 func (a *App) RunAndWait() (err error) {
 	n, err := a.Run()
 	if err != nil {
@@ -134,11 +295,16 @@ func (a *App) RunAndWait() (err error) {
 
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGKILL)
+	defer signal.Stop(signals)
 
-	for range signals {
-		signal.Stop(signals)
-		close(signals)
-		a.Shutdown()
+	select {
+	case <-signals:
+	case err = <-a.failed:
+		err = fmt.Errorf("run failed: %w", err)
+	}
+
+	if e := a.Shutdown(); e != nil {
+		err = errors.Join(err, fmt.Errorf("shutdown failed: %w", e))
 	}
 
 	return
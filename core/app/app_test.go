@@ -0,0 +1,235 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goslacker/slacker/core/container"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+type testComponent struct {
+	Component
+	name  string
+	deps  []string
+	log   *[]string
+	hang  bool
+	stopC chan struct{}
+}
+
+func newTestComponent(name string, log *[]string, deps ...string) *testComponent {
+	return &testComponent{name: name, deps: deps, log: log, stopC: make(chan struct{})}
+}
+
+func (c *testComponent) Name() string        { return c.name }
+func (c *testComponent) DependsOn() []string { return c.deps }
+
+func (c *testComponent) Init() error {
+	if c.hang {
+		time.Sleep(time.Second)
+	}
+	*c.log = append(*c.log, "init "+c.name)
+	return nil
+}
+
+func (c *testComponent) Start() {
+	<-c.stopC
+}
+
+func (c *testComponent) Stop() {
+	*c.log = append(*c.log, "stop "+c.name)
+	close(c.stopC)
+}
+
+type optionalComponent struct {
+	*testComponent
+	optional []string
+}
+
+func (c *optionalComponent) OptionalDependsOn() []string {
+	return c.optional
+}
+
+type readyComponent struct {
+	*testComponent
+	ready error
+}
+
+func (c *readyComponent) Ready() error {
+	return c.ready
+}
+
+type failComponent struct {
+	Component
+	name string
+	err  error
+}
+
+func (c *failComponent) Name() string { return c.name }
+func (c *failComponent) Serve() error { return c.err }
+func (c *failComponent) Stop()        {}
+
+type panicComponent struct {
+	Component
+}
+
+func (c *panicComponent) Start() { panic(errors.New("bad config")) }
+func (c *panicComponent) Stop()  {}
+
+func TestApp_Lifecycle(t *testing.T) {
+	require.NoError(t, Bind[*viper.Viper](viper.New()))
+
+	t.Run("init in dependency order and stop in reverse", func(t *testing.T) {
+		var log []string
+		a := NewApp()
+		a.RegisterComponent(
+			newTestComponent("worker", &log, "grpc"),
+			newTestComponent("grpc", &log, "db"),
+			newTestComponent("db", &log),
+		)
+
+		n, err := a.Run()
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.NoError(t, a.Shutdown())
+		require.Equal(t, []string{
+			"init db", "init grpc", "init worker",
+			"stop worker", "stop grpc", "stop db",
+		}, log)
+	})
+
+	t.Run("extra dependency", func(t *testing.T) {
+		var log []string
+		a := NewApp()
+		a.RegisterComponent(newTestComponent("a", &log), newTestComponent("b", &log))
+		a.DependsOn("a", "b")
+
+		names, err := a.Components()
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "a"}, names)
+	})
+
+	t.Run("optional dependency", func(t *testing.T) {
+		var log []string
+		a := NewApp()
+		a.RegisterComponent(
+			&optionalComponent{newTestComponent("worker", &log), []string{"grpc", "mq"}},
+			newTestComponent("grpc", &log),
+		)
+
+		_, err := a.Run()
+		require.NoError(t, err)
+		require.NoError(t, a.Shutdown())
+		require.Equal(t, []string{"init grpc", "init worker", "stop worker", "stop grpc"}, log)
+	})
+
+	t.Run("circular dependency", func(t *testing.T) {
+		var log []string
+		a := NewApp()
+		a.RegisterComponent(
+			newTestComponent("a", &log, "b"),
+			newTestComponent("b", &log, "c"),
+			newTestComponent("c", &log, "a"),
+		)
+
+		_, err := a.Components()
+		require.ErrorContains(t, err, "a -> b -> c -> a")
+	})
+
+	t.Run("unknown dependency", func(t *testing.T) {
+		var log []string
+		a := NewApp()
+		a.RegisterComponent(newTestComponent("a", &log, "b"))
+
+		_, err := a.Run()
+		require.ErrorContains(t, err, "component <a> depends on unknown component <b>")
+	})
+
+	t.Run("init timeout", func(t *testing.T) {
+		var log []string
+		c := newTestComponent("slow", &log)
+		c.hang = true
+		a := NewApp(WithTimeout(PhaseInit, 10*time.Millisecond))
+		a.RegisterComponent(c)
+
+		err := a.Init()
+		require.ErrorIs(t, err, ErrTimeout)
+		var ce *ComponentError
+		require.True(t, errors.As(err, &ce))
+		require.Equal(t, "slow", ce.Component)
+		require.Equal(t, PhaseInit, ce.Phase)
+	})
+
+	t.Run("wait ready", func(t *testing.T) {
+		var log []string
+		c := &readyComponent{testComponent: newTestComponent("grpc", &log), ready: errors.New("not listening")}
+		a := NewApp(WithTimeout(PhaseStart, 100*time.Millisecond))
+		a.RegisterComponent(c)
+
+		_, err := a.Run()
+		require.ErrorIs(t, err, ErrTimeout)
+		require.ErrorContains(t, err, "not listening")
+		require.Equal(t, []string{"init grpc", "stop grpc"}, log)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		var log []string
+		c := &readyComponent{testComponent: newTestComponent("grpc", &log)}
+		a := NewApp()
+		a.RegisterComponent(c)
+		require.False(t, a.Status().Ready)
+
+		_, err := a.Run()
+		require.NoError(t, err)
+		status := a.Status()
+		require.True(t, status.Ready)
+		require.True(t, status.Healthy)
+
+		c.ready = errors.New("overload")
+		status = a.Status()
+		require.False(t, status.Ready)
+		require.True(t, status.Healthy)
+		require.Equal(t, "overload", status.Components[0].Error)
+
+		c.Stop()
+		<-a.sorted[0].done
+		status = a.Status()
+		require.False(t, status.Healthy)
+		require.Contains(t, status.Components[0].Error, ErrExited.Error())
+	})
+
+	t.Run("start failure", func(t *testing.T) {
+		var log []string
+		a := NewApp()
+		a.RegisterComponent(
+			newTestComponent("db", &log),
+			&failComponent{name: "grpc", err: errors.New("port taken")},
+		)
+
+		err := a.RunAndWait()
+		require.ErrorContains(t, err, "component <grpc> start failed: port taken")
+		require.Equal(t, []string{"init db", "stop db"}, log)
+	})
+
+	t.Run("start panic", func(t *testing.T) {
+		a := NewApp()
+		a.RegisterComponent(&panicComponent{})
+
+		err := a.RunAndWait()
+		require.ErrorContains(t, err, "panic: bad config")
+	})
+
+	t.Run("validate container", func(t *testing.T) {
+		type missing struct{}
+		type service struct{ m *missing }
+		require.NoError(t, Bind[*service](func(m *missing) *service { return &service{m: m} }))
+		defer func() {
+			require.NoError(t, Bind[*missing](&missing{}))
+		}()
+
+		_, err := NewApp().Run()
+		require.ErrorIs(t, err, container.ErrMissingBinding)
+	})
+}
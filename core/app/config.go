@@ -1,13 +1,17 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/goslacker/slacker/core/container"
 
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -18,9 +22,15 @@ const (
 	ModeRelease = "release"
 )
 
+const defaultEnvFile = ".env"
+
 type configOpt struct {
-	config string
-	path   string
+	config  string
+	path    string
+	watch   bool
+	mode    string
+	envFile string
+	flags   *pflag.FlagSet
 }
 
 func WithPath(path string) func(*configOpt) {
@@ -35,48 +45,231 @@ func WithContent(content string) func(*configOpt) {
 	}
 }
 
+// WithWatch 监听配置文件变化并自动重新加载, 仅在使用 WithPath 时生效
+func WithWatch() func(*configOpt) {
+	return func(opt *configOpt) {
+		opt.watch = true
+	}
+}
+
+// WithMode 指定运行模式, 优先于各层配置中的 mode, 用于选择 config.<mode>.yaml
+func WithMode(mode string) func(*configOpt) {
+	return func(opt *configOpt) {
+		opt.mode = mode
+	}
+}
+
+// WithEnvFile 指定 .env 文件路径, 默认读取工作目录下的 .env, 不存在时跳过
+func WithEnvFile(path string) func(*configOpt) {
+	return func(opt *configOpt) {
+		opt.envFile = path
+	}
+}
+
+// WithFlags 绑定命令行参数, 参数名即配置路径, 如 --grpcx.addr, 只有显式传入的参数会覆盖配置
+func WithFlags(flags *pflag.FlagSet) func(*configOpt) {
+	return func(opt *configOpt) {
+		opt.flags = flags
+	}
+}
+
+// LoadConfig 加载配置, 优先级从低到高为:
+//  1. 基础配置, WithPath 指定的文件或 WithContent 指定的内容
+//  2. 模式配置, 与基础配置文件同目录的 <name>.<mode>.yaml, 如 config.develop.yaml
+//  3. .env 文件, 键名中的 __ 表示层级, 如 GORMX__DSN 对应 gormx.dsn
+//  4. 环境变量, 规则同 .env
+//  5. 命令行参数
+//
+// 配置值中的 ${file:path}, ${env:NAME}, ${base64:content} 等引用在加载时解析, 见 RegisterSecretResolver
 func LoadConfig(opts ...func(*configOpt)) (err error) {
 	m := &configOpt{}
 	for _, opt := range opts {
 		opt(m)
 	}
 
-	viper.SetConfigType("yaml")
-
-	if m.path != "" {
-		m.config = readConfig(m.path)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "__"))
+	viper.AutomaticEnv()
+	if m.flags != nil {
+		err = viper.BindPFlags(m.flags)
+		if err != nil {
+			return
+		}
 	}
 
-	err = viper.ReadConfig(strings.NewReader(m.config))
+	err = m.load()
 	if err != nil {
 		return
 	}
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "__"))
-	viper.AutomaticEnv()
+	watcher.loaded(m)
 	err = container.Bind[*viper.Viper](viper.GetViper)
 	if err != nil {
 		return
 	}
 
-	if viper.GetString("mode") == "" {
-		viper.Set("mode", ModeLocal)
-	}
+	viper.SetDefault("mode", ModeLocal)
 	setLog(viper.GetViper())
+	slog.Debug("config loaded", "sources", ConfigSources())
+
+	if m.watch && m.path != "" {
+		err = watchConfig()
+	}
+
+	return
+}
+
+// load 按优先级合并基础配置, 模式配置和 .env 文件, 环境变量和命令行参数由 viper 在读取时覆盖
+func (m *configOpt) load() (err error) {
+	layers, err := m.readLayers()
+	if err != nil {
+		return
+	}
+
+	viper.SetConfigType("yaml")
+	err = viper.ReadConfig(strings.NewReader(layers.content))
+	if err != nil {
+		return
+	}
+	// 基础配置中的引用已经解析, 需要覆盖原始内容
+	for _, layer := range []map[string]any{layers.base, layers.profile, layers.dotEnv} {
+		if len(layer) == 0 {
+			continue
+		}
+		err = viper.MergeConfigMap(layer)
+		if err != nil {
+			return
+		}
+	}
+
+	// 环境变量和命令行参数在读取时才覆盖配置, 含有引用时解析后直接设置
+	for _, key := range viper.AllKeys() {
+		if !hasEnv(key) && (m.flags == nil || !m.flags.Changed(key)) {
+			continue
+		}
+		value, ok := viper.Get(key).(string)
+		if !ok {
+			continue
+		}
+		resolved, ok, e := resolveRefs(value)
+		if e != nil {
+			return fmt.Errorf("config <%s>: %w", key, e)
+		}
+		if ok {
+			viper.Set(key, resolved)
+			layers.secrets[key] = struct{}{}
+		}
+	}
+
+	// 模式可能来自 WithMode, 命令行参数或环境变量 MODE, 写回配置使 mode 与加载的模式配置一致
+	viper.Set("mode", layers.mode)
+
+	sourceLock.Lock()
+	defer sourceLock.Unlock()
+	current = layers
+	return
+}
+
+func (m *configOpt) readLayers() (layers *configLayers, err error) {
+	layers = &configLayers{opt: m, content: m.config, secrets: make(map[string]struct{})}
+	if m.path != "" {
+		layers.content = readConfig(m.path)
+	}
+	layers.base, err = parseYaml(layers.content)
+	if err != nil {
+		return nil, fmt.Errorf("parse config failed: %w", err)
+	}
+
+	envFile := m.envFile
+	if envFile == "" {
+		envFile = defaultEnvFile
+	}
+	values, err := readDotEnv(envFile)
+	if err != nil && (m.envFile != "" || !os.IsNotExist(err)) {
+		return nil, fmt.Errorf("read env file <%s> failed: %w", envFile, err)
+	}
+	err = nil
+	layers.dotEnv = dotEnvSettings(values)
+	err = errors.Join(resolveSettings("", layers.base, layers.secrets), resolveSettings("", layers.dotEnv, layers.secrets))
+	if err != nil {
+		return nil, fmt.Errorf("resolve config failed: %w", err)
+	}
 
+	layers.mode = m.currentMode(layers)
+	if path := m.profilePath(layers.mode); path != "" {
+		if _, e := os.Stat(path); e == nil {
+			layers.profile, err = parseYaml(readConfig(path))
+			if err != nil {
+				return nil, fmt.Errorf("parse config <%s> failed: %w", path, err)
+			}
+			err = resolveSettings("", layers.profile, layers.secrets)
+			if err != nil {
+				return nil, fmt.Errorf("resolve config <%s> failed: %w", path, err)
+			}
+		}
+	}
 	return
 }
 
+// currentMode 按配置优先级确定运行模式, 模式配置本身不能修改 mode
+func (m *configOpt) currentMode(layers *configLayers) string {
+	if m.mode != "" {
+		return m.mode
+	}
+	if m.flags != nil {
+		if f := m.flags.Lookup("mode"); f != nil && f.Changed {
+			return f.Value.String()
+		}
+	}
+	if mode, ok := os.LookupEnv("MODE"); ok && mode != "" {
+		return mode
+	}
+	for _, settings := range []map[string]any{layers.dotEnv, layers.base} {
+		if mode, ok := settings["mode"].(string); ok && mode != "" {
+			return mode
+		}
+	}
+	return ModeLocal
+}
+
+// profilePath 模式配置路径, 基础配置为 conf/config.yaml 时返回 conf/config.<mode>.yaml
+func (m *configOpt) profilePath(mode string) string {
+	if m.path == "" || mode == "" {
+		return ""
+	}
+	ext := filepath.Ext(m.path)
+	return strings.TrimSuffix(m.path, ext) + "." + mode + ext
+}
+
+func parseYaml(content string) (settings map[string]any, err error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err = v.ReadConfig(strings.NewReader(content))
+	if err != nil {
+		return
+	}
+	return v.AllSettings(), nil
+}
+
+// logLevel 日志级别, 配置重新加载后可以直接修改而不用替换 handler
+var logLevel = new(slog.LevelVar)
+
 func setLog(c *viper.Viper) {
+	setLogLevel(c)
 	opts := &slog.HandlerOptions{
 		AddSource: true,
-	}
-	if c.GetString("mode") == ModeLocal || c.GetBool("showDebugLog") {
-		opts.Level = slog.LevelDebug
+		Level:     logLevel,
 	}
 
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, opts)))
 }
 
+func setLogLevel(c *viper.Viper) {
+	if c.GetString("mode") == ModeLocal || c.GetBool("showDebugLog") {
+		logLevel.Set(slog.LevelDebug)
+	} else {
+		logLevel.Set(slog.LevelInfo)
+	}
+}
+
 func readConfig(path string) (result string) {
 	f, err := os.Open(path)
 	if err != nil {
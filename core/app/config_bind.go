@@ -0,0 +1,305 @@
+package app
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// ConfigFieldError 单个配置项的错误
+type ConfigFieldError struct {
+	Field   string
+	Message string
+}
+
+// ConfigError 读取配置失败, 包含所有不合法的配置项
+type ConfigError struct {
+	Key    string
+	Fields []ConfigFieldError
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, 0, len(e.Fields)+1)
+	if e.Err != nil {
+		msgs = append(msgs, e.Err.Error())
+	}
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+	return fmt.Sprintf("invalid config <%s>: %s", e.Key, strings.Join(msgs, "; "))
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Config 把 key 对应的配置读取到结构体中, key 为空时读取全部配置
+//   - 字段名通过 mapstructure 标签指定, 未指定时为字段名小写
+//   - 配置缺失时使用 default 标签的值, 如 `default:"200"`, 时间可以写为 `default:"5s"`, 切片用逗号分隔
+//   - 读取后按 validate 标签校验, 如 `validate:"required,min=1,max=4"`
+//
+// 数字, 字符串, 布尔值之间会自动转换, 类型不匹配或校验失败时一次返回所有不合法的配置项
+func Config[T any](key string) (cfg T, err error) {
+	key = strings.ToLower(key)
+	settings := subSettings(key)
+	lookupFields(reflect.TypeOf(cfg), key, settings)
+	applyDefaults(reflect.TypeOf(cfg), settings)
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &cfg,
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+			mapstructure.TextUnmarshallerHookFunc(),
+		),
+	})
+	if err != nil {
+		return
+	}
+	if e := decoder.Decode(settings); e != nil {
+		if fields := decodeErrors(e); len(fields) > 0 {
+			err = &ConfigError{Key: key, Fields: fields}
+		} else {
+			err = &ConfigError{Key: key, Err: e}
+		}
+		return
+	}
+
+	if fields := validateConfig(cfg); len(fields) > 0 {
+		err = &ConfigError{Key: key, Fields: fields}
+	}
+	return
+}
+
+// subSettings 逐个读取 key 下的配置项, 使环境变量和命令行参数的覆盖生效
+func subSettings(key string) (settings map[string]any) {
+	settings = make(map[string]any)
+	prefix := ""
+	if key != "" {
+		prefix = key + "."
+	}
+	for _, k := range viper.AllKeys() {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		m := settings
+		parts := strings.Split(strings.TrimPrefix(k, prefix), ".")
+		for _, part := range parts[:len(parts)-1] {
+			sub, ok := m[part].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				m[part] = sub
+			}
+			m = sub
+		}
+		m[parts[len(parts)-1]] = viper.Get(k)
+	}
+	return
+}
+
+// lookupFields 按结构体字段逐个读取 AllKeys 中没有的配置项, 只在环境变量中设置的配置项不会出现在 AllKeys 中
+func lookupFields(t reflect.Type, key string, settings map[string]any) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	prefix := ""
+	if key != "" {
+		prefix = key + "."
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, squash := fieldName(f)
+		if squash {
+			lookupFields(f.Type, key, settings)
+			continue
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != reflect.TypeOf(time.Time{}) && !reflect.PointerTo(ft).Implements(textUnmarshalerType) {
+			sub, ok := settings[name].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+			}
+			lookupFields(ft, prefix+name, sub)
+			if len(sub) > 0 {
+				settings[name] = sub
+			}
+			continue
+		}
+		if _, ok := settings[name]; ok {
+			continue
+		}
+		if value := viper.Get(prefix + name); value != nil {
+			settings[name] = value
+		}
+	}
+}
+
+// decodeErrors 把解码错误拆分为每个配置项的错误
+func decodeErrors(err error) (fields []ConfigFieldError) {
+	if de, ok := err.(*mapstructure.DecodeError); ok {
+		return []ConfigFieldError{{Field: de.Name(), Message: de.Unwrap().Error()}}
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, item := range e.Unwrap() {
+			fields = append(fields, decodeErrors(item)...)
+		}
+	case interface{ Unwrap() error }:
+		fields = decodeErrors(e.Unwrap())
+	}
+	return
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// applyDefaults 把 default 标签的值填入缺失的配置项, 由解码时统一转换类型
+func applyDefaults(t reflect.Type, settings map[string]any) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, squash := fieldName(f)
+		if squash {
+			applyDefaults(f.Type, settings)
+			continue
+		}
+		if _, ok := settings[name]; !ok {
+			if value, ok := f.Tag.Lookup("default"); ok {
+				settings[name] = value
+				continue
+			}
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			// 指针类型的结构体只有配置存在时才填充默认值, 保留 nil 表示未配置
+			if sub, ok := settings[name].(map[string]any); ok {
+				applyDefaults(ft, sub)
+			}
+			continue
+		}
+		if ft.Kind() != reflect.Struct || ft == reflect.TypeOf(time.Time{}) {
+			continue
+		}
+		sub, ok := settings[name].(map[string]any)
+		if !ok {
+			sub = make(map[string]any)
+		}
+		applyDefaults(ft, sub)
+		if len(sub) > 0 {
+			settings[name] = sub
+		}
+	}
+}
+
+// fieldName 配置项名称, 与 mapstructure 的规则一致, 配置路径统一为小写
+func fieldName(f reflect.StructField) (name string, squash bool) {
+	tag := f.Tag.Get("mapstructure")
+	name, opts, _ := strings.Cut(tag, ",")
+	if strings.Contains(opts, "squash") || (f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct) {
+		return "", true
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return strings.ToLower(name), false
+}
+
+var configValidator = func() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _ := fieldName(f)
+		return name
+	})
+	return v
+}()
+
+func validateConfig(cfg any) (fields []ConfigFieldError) {
+	v := reflect.ValueOf(cfg)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	err := configValidator.Struct(cfg)
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return
+	}
+	for _, e := range errs {
+		field := e.Namespace()
+		// 去掉结构体名
+		if _, after, ok := strings.Cut(field, "."); ok {
+			field = after
+		}
+		fields = append(fields, ConfigFieldError{Field: field, Message: validateMessage(e)})
+	}
+	return
+}
+
+func validateMessage(e validator.FieldError) string {
+	length := ""
+	switch e.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		length = "length "
+	}
+	param := e.Param()
+	if e.Type() == durationType {
+		if d, err := time.ParseDuration(param); err == nil {
+			param = d.String()
+		}
+	}
+
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("%smust be >= %s", length, param)
+	case "max", "lte":
+		return fmt.Sprintf("%smust be <= %s", length, param)
+	case "gt":
+		return fmt.Sprintf("%smust be > %s", length, param)
+	case "lt":
+		return fmt.Sprintf("%smust be < %s", length, param)
+	case "len":
+		return fmt.Sprintf("%smust be %s", length, param)
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %v", param, e.Value())
+	default:
+		if param != "" {
+			return fmt.Sprintf("failed on %s=%s", e.Tag(), param)
+		}
+		return "failed on " + e.Tag()
+	}
+}
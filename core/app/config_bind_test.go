@@ -0,0 +1,91 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testLoggerConfig struct {
+	SlowThreshold time.Duration `mapstructure:"slow_threshold" default:"200ms"`
+	LogLevel      int           `mapstructure:"log_level" default:"4" validate:"min=1,max=4"`
+	Colorful      bool          `mapstructure:"colorful" default:"true"`
+}
+
+type testDBConfig struct {
+	DSN    string           `mapstructure:"dsn" validate:"required"`
+	Tables []string         `mapstructure:"tables" default:"a,b"`
+	Logger testLoggerConfig `mapstructure:"logger"`
+}
+
+func TestConfig(t *testing.T) {
+	t.Run("defaults and conversion", func(t *testing.T) {
+		require.NoError(t, LoadConfig(WithContent(`
+db:
+  dsn: mysql://root@tcp(127.0.0.1:3306)/test
+  logger:
+    log_level: "2"
+    colorful: false
+`)))
+		cfg, err := Config[testDBConfig]("db")
+		require.NoError(t, err)
+		require.Equal(t, testDBConfig{
+			DSN:    "mysql://root@tcp(127.0.0.1:3306)/test",
+			Tables: []string{"a", "b"},
+			Logger: testLoggerConfig{SlowThreshold: 200 * time.Millisecond, LogLevel: 2, Colorful: false},
+		}, cfg)
+	})
+
+	t.Run("env override", func(t *testing.T) {
+		t.Setenv("DB__LOGGER__LOG_LEVEL", "3")
+		cfg, err := Config[testDBConfig]("db")
+		require.NoError(t, err)
+		require.Equal(t, 3, cfg.Logger.LogLevel)
+	})
+
+	t.Run("env only", func(t *testing.T) {
+		t.Setenv("DB__LOGGER__SLOW_THRESHOLD", "1s")
+		t.Setenv("DB__TABLES", "x,y")
+		cfg, err := Config[testDBConfig]("db")
+		require.NoError(t, err)
+		require.Equal(t, time.Second, cfg.Logger.SlowThreshold)
+		require.Equal(t, []string{"x", "y"}, cfg.Tables)
+
+		t.Setenv("CACHE__DB__DSN", "redis://127.0.0.1:6379")
+		nested, err := Config[struct {
+			DB *testDBConfig `mapstructure:"db"`
+		}]("cache")
+		require.NoError(t, err)
+		require.Equal(t, "redis://127.0.0.1:6379", nested.DB.DSN)
+		require.Equal(t, 4, nested.DB.Logger.LogLevel)
+	})
+
+	t.Run("report every bad field", func(t *testing.T) {
+		require.NoError(t, LoadConfig(WithContent(`
+db:
+  logger:
+    log_level: 9
+`)))
+		_, err := Config[testDBConfig]("DB")
+		var ce *ConfigError
+		require.True(t, errors.As(err, &ce))
+		require.Equal(t, "invalid config <db>: dsn is required; logger.log_level must be <= 4", err.Error())
+	})
+
+	t.Run("bad type", func(t *testing.T) {
+		require.NoError(t, LoadConfig(WithContent(`
+db:
+  dsn: x
+  logger:
+    log_level: high
+    slow_threshold: slow
+`)))
+		_, err := Config[testDBConfig]("db")
+		var ce *ConfigError
+		require.True(t, errors.As(err, &ce))
+		require.Len(t, ce.Fields, 2)
+		require.ElementsMatch(t, []string{"logger.log_level", "logger.slow_threshold"}, []string{ce.Fields[0].Field, ce.Fields[1].Field})
+	})
+}
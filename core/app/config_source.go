@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// 配置来源, 按优先级从低到高排列
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceProfile = "profile"
+	SourceDotEnv  = "dotenv"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+var (
+	sourceLock sync.RWMutex
+	current    *configLayers
+)
+
+// configLayers 最近一次加载的各层配置, 用于判断生效的配置来自哪一层
+type configLayers struct {
+	opt     *configOpt
+	content string
+	mode    string
+	base    map[string]any
+	profile map[string]any
+	dotEnv  map[string]any
+	secrets map[string]struct{} // 值来自引用的配置路径
+}
+
+// ConfigSource 生效的配置项及其来源
+type ConfigSource struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// ConfigSources 返回所有生效的配置项及其来源, 按配置路径排序, 敏感配置的值会被隐藏
+func ConfigSources() (sources []ConfigSource) {
+	sourceLock.RLock()
+	layers := current
+	sourceLock.RUnlock()
+
+	flat := make(map[string]map[string]any)
+	if layers != nil {
+		for name, settings := range map[string]map[string]any{
+			SourceFile:    layers.base,
+			SourceProfile: layers.profile,
+			SourceDotEnv:  layers.dotEnv,
+		} {
+			flat[name] = make(map[string]any)
+			flattenSettings("", settings, flat[name])
+		}
+	}
+
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		source := SourceDefault
+		switch {
+		case layers != nil && layers.opt.flags != nil && layers.opt.flags.Changed(key):
+			source = SourceFlag
+		case hasEnv(key):
+			source = SourceEnv
+		default:
+			for _, name := range []string{SourceDotEnv, SourceProfile, SourceFile} {
+				if _, ok := flat[name][key]; ok {
+					source = name
+					break
+				}
+			}
+		}
+		value := viper.Get(key)
+		if layers != nil && isSensitive(key, layers.secrets) {
+			value = redacted
+		}
+		sources = append(sources, ConfigSource{Key: key, Value: value, Source: source})
+	}
+	return
+}
+
+// DumpConfig 以文本形式输出所有生效的配置项及其来源, 便于排查配置问题
+func DumpConfig(w io.Writer) (err error) {
+	for _, s := range ConfigSources() {
+		_, err = fmt.Fprintf(w, "%s = %v (%s)\n", s.Key, s.Value, s.Source)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func hasEnv(key string) bool {
+	_, ok := os.LookupEnv(envName(key))
+	return ok
+}
+
+// envName 配置路径对应的环境变量名, 与 viper 的 AutomaticEnv 规则一致
+func envName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "__"))
+}
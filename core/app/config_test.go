@@ -0,0 +1,79 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Layers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("config.yaml", `
+mode: develop
+a: 1
+b:
+  c: base
+  d: base
+name: base
+`)
+	write("config.develop.yaml", `
+a: 2
+b:
+  c: profile
+`)
+	write(".env", `
+# comment
+export B__D="dotenv"
+NAME=dotenv # inline comment
+`)
+	t.Setenv("NAME", "env")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("port", "80", "")
+	require.NoError(t, flags.Parse([]string{"--port=8080"}))
+
+	require.NoError(t, LoadConfig(
+		WithPath(filepath.Join(dir, "config.yaml")),
+		WithEnvFile(filepath.Join(dir, ".env")),
+		WithFlags(flags),
+	))
+
+	require.Equal(t, 2, viper.GetInt("a"))
+	require.Equal(t, "profile", viper.GetString("b.c"))
+	require.Equal(t, "dotenv", viper.GetString("b.d"))
+	require.Equal(t, "env", viper.GetString("name"))
+	require.Equal(t, "8080", viper.GetString("port"))
+
+	sources := make(map[string]string)
+	for _, s := range ConfigSources() {
+		sources[s.Key] = s.Source
+	}
+	require.Equal(t, map[string]string{
+		"mode": SourceFile,
+		"a":    SourceProfile,
+		"b.c":  SourceProfile,
+		"b.d":  SourceDotEnv,
+		"name": SourceEnv,
+		"port": SourceFlag,
+	}, sources)
+
+	var buf bytes.Buffer
+	require.NoError(t, DumpConfig(&buf))
+	require.Contains(t, buf.String(), "b.d = dotenv (dotenv)\n")
+
+	t.Run("mode override", func(t *testing.T) {
+		require.NoError(t, Reload(WithPath(filepath.Join(dir, "config.yaml")), WithMode(ModeRelease)))
+		require.Equal(t, ModeRelease, viper.GetString("mode"))
+		require.Equal(t, "base", viper.GetString("b.c"))
+		require.Equal(t, 1, viper.GetInt("a"))
+		require.Equal(t, "8080", viper.GetString("port"))
+	})
+}
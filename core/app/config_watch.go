@@ -0,0 +1,232 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+var watcher = &configWatcher{}
+
+// configWatcher 保存上一次加载的配置快照, 重新加载后与新配置对比并触发变更事件
+type configWatcher struct {
+	lock     sync.Mutex
+	opt      *configOpt
+	snapshot map[string]any
+}
+
+func (w *configWatcher) loaded(opt *configOpt) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.opt = opt
+	w.snapshot = viper.AllSettings()
+}
+
+// apply 对比当前配置与快照, 按变化的配置路径触发 ConfigChanged, 最后触发 ConfigReloaded
+func (w *configWatcher) apply() (err error) {
+	w.lock.Lock()
+	old := w.snapshot
+	current := viper.AllSettings()
+	w.snapshot = current
+	w.lock.Unlock()
+
+	changed := diffSettings(old, current)
+	if len(changed) == 0 {
+		return
+	}
+	var errs []error
+	for _, key := range changed {
+		e := Fire(ConfigChanged{
+			Key: key,
+			Old: lookupSetting(old, key),
+			New: lookupSetting(current, key),
+		})
+		if e != nil {
+			errs = append(errs, fmt.Errorf("handle config change <%s> failed: %w", key, e))
+		}
+	}
+	if e := Fire(ConfigReloaded{Changed: changed}); e != nil {
+		errs = append(errs, e)
+	}
+	return errors.Join(errs...)
+}
+
+// Reload 重新读取配置并触发变更事件, 不传参数时按 LoadConfig 时的来源重新合并各层配置, 也可以通过 WithPath/WithContent 指定新的来源
+func Reload(opts ...func(*configOpt)) (err error) {
+	watcher.lock.Lock()
+	m := &configOpt{}
+	if watcher.opt != nil {
+		*m = *watcher.opt
+	}
+	watcher.lock.Unlock()
+	if len(opts) > 0 {
+		flags := m.flags
+		m = &configOpt{}
+		for _, opt := range opts {
+			opt(m)
+		}
+		if m.flags == nil {
+			m.flags = flags
+		}
+	}
+
+	err = m.load()
+	if err != nil {
+		return fmt.Errorf("reload config failed: %w", err)
+	}
+
+	watcher.lock.Lock()
+	watcher.opt = m
+	watcher.lock.Unlock()
+	return watcher.apply()
+}
+
+// watchConfig 监听基础配置, 模式配置和 .env 文件所在目录, 文件变化后稍作等待再重新加载, 避免编辑器多次写入导致重复加载
+func watchConfig() (err error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create config watcher failed: %w", err)
+	}
+
+	watched := func() map[string]struct{} {
+		sourceLock.RLock()
+		layers := current
+		sourceLock.RUnlock()
+		files := make(map[string]struct{})
+		if layers == nil {
+			return files
+		}
+		envFile := layers.opt.envFile
+		if envFile == "" {
+			envFile = defaultEnvFile
+		}
+		for _, path := range []string{layers.opt.path, layers.opt.profilePath(layers.mode), envFile} {
+			if path != "" {
+				files[filepath.Clean(path)] = struct{}{}
+			}
+		}
+		return files
+	}
+
+	dirs := make(map[string]struct{})
+	for path := range watched() {
+		dirs[filepath.Dir(path)] = struct{}{}
+	}
+	for dir := range dirs {
+		err = w.Add(dir)
+		if err != nil {
+			_ = w.Close()
+			return fmt.Errorf("watch config dir <%s> failed: %w", dir, err)
+		}
+	}
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+				if _, ok := watched()[filepath.Clean(event.Name)]; !ok {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(100*time.Millisecond, func() {
+					if err := Reload(); err != nil {
+						slog.Error("apply config change failed", "error", err)
+					}
+				})
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("watch config failed", "error", err)
+			}
+		}
+	}()
+	return
+}
+
+// OnConfigChange 注册只关心某个配置路径变化的监听器, key 不区分大小写
+func OnConfigChange(key string, f func(event ConfigChanged) error) {
+	key = strings.ToLower(key)
+	RegisterListener(func(event ConfigChanged) error {
+		if event.Key != key {
+			return nil
+		}
+		return f(event)
+	})
+}
+
+// diffSettings 找出新旧配置中发生变化的叶子路径及其所有上级路径, 按路径排序
+func diffSettings(old, current map[string]any) (changed []string) {
+	oldFlat := make(map[string]any)
+	flattenSettings("", old, oldFlat)
+	currentFlat := make(map[string]any)
+	flattenSettings("", current, currentFlat)
+
+	set := make(map[string]struct{})
+	mark := func(key string) {
+		parts := strings.Split(key, ".")
+		for i := range parts {
+			set[strings.Join(parts[:i+1], ".")] = struct{}{}
+		}
+	}
+	for key, v := range oldFlat {
+		if nv, ok := currentFlat[key]; !ok || !reflect.DeepEqual(v, nv) {
+			mark(key)
+		}
+	}
+	for key := range currentFlat {
+		if _, ok := oldFlat[key]; !ok {
+			mark(key)
+		}
+	}
+
+	for key := range set {
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	return
+}
+
+func flattenSettings(prefix string, settings map[string]any, result map[string]any) {
+	for k, v := range settings {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]any); ok && len(sub) > 0 {
+			flattenSettings(key, sub, result)
+			continue
+		}
+		result[key] = v
+	}
+}
+
+func lookupSetting(settings map[string]any, key string) (value any) {
+	value = settings
+	for _, part := range strings.Split(key, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[part]
+	}
+	return
+}
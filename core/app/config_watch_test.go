@@ -0,0 +1,49 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	require.NoError(t, LoadConfig(WithContent(`
+mode: release
+gormx:
+  dsn: mysql://root@tcp(127.0.0.1:3306)/test
+  logger:
+    log_level: 4
+`)))
+
+	var changed []ConfigChanged
+	OnConfigChange("gormx.logger", func(event ConfigChanged) error {
+		changed = append(changed, event)
+		return nil
+	})
+	var reloaded []string
+	RegisterListener(func(event ConfigReloaded) error {
+		reloaded = event.Changed
+		return nil
+	})
+
+	require.NoError(t, Reload(WithContent(`
+mode: release
+gormx:
+  dsn: mysql://root@tcp(127.0.0.1:3306)/test
+  logger:
+    log_level: 2
+    colorful: false
+`)))
+	require.Len(t, changed, 1)
+	require.Equal(t, map[string]any{"log_level": 4}, changed[0].Old)
+	require.Equal(t, map[string]any{"log_level": 2, "colorful": false}, changed[0].New)
+	require.Equal(t, []string{"gormx", "gormx.logger", "gormx.logger.colorful", "gormx.logger.log_level"}, reloaded)
+
+	t.Run("no change", func(t *testing.T) {
+		changed = nil
+		reloaded = nil
+		require.NoError(t, Reload())
+		require.Empty(t, changed)
+		require.Empty(t, reloaded)
+	})
+}
@@ -1,5 +1,7 @@
 package app
 
+import "time"
+
 // Component 组件。
 type Component interface {
 	_component()
@@ -21,3 +23,46 @@ type Serviceable interface {
 	//Stop 停止服务并阻塞, 报错应打日志记录
 	Stop()
 }
+
+// ServiceableWithError 与 Serviceable 相同, 但启动或运行失败时通过返回值报告错误, 两者都实现时优先使用本接口
+type ServiceableWithError interface {
+	//Serve 启动服务并阻塞, 框架一般会将这个方法作为协程调用, 被 Stop 正常停止时应返回nil
+	Serve() error
+	//Stop 停止服务并阻塞, 报错应打日志记录
+	Stop()
+}
+
+// Named 表示组件有自己的名称, 用于声明依赖和错误报告, 未实现时使用类型名
+type Named interface {
+	Name() string
+}
+
+// Dependent 表示组件依赖其他组件, 被依赖的组件先 Init/Boot/Start, 后 Stop
+type Dependent interface {
+	DependsOn() []string
+}
+
+// OptionalDependent 表示组件依赖的其他组件已注册时才依赖, 未注册时忽略, 用于内置组件之间的依赖
+type OptionalDependent interface {
+	OptionalDependsOn() []string
+}
+
+// Prioritized 表示组件有优先级, 无依赖关系的组件按优先级从小到大排序, 相同时按注册顺序
+type Prioritized interface {
+	Priority() int
+}
+
+// PhaseTimeouter 表示组件自定义各阶段的超时时间, 返回0时使用 App 的默认值
+type PhaseTimeouter interface {
+	Timeout(phase Phase) time.Duration
+}
+
+// Readiness 表示组件可以报告是否已准备好对外服务, 返回nil表示就绪
+type Readiness interface {
+	Ready() error
+}
+
+// Liveness 表示组件可以报告自身是否存活, 返回nil表示健康
+type Liveness interface {
+	Healthy() error
+}
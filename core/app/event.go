@@ -15,3 +15,15 @@ type AfterRun struct{}
 type BeforeShutdown struct{}
 
 type AfterShutdown struct{}
+
+// ConfigChanged 配置重新加载后, 每个发生变化的配置路径(包括其上级路径)各触发一次, 路径为小写
+type ConfigChanged struct {
+	Key string
+	Old any
+	New any
+}
+
+// ConfigReloaded 配置重新加载完成, 在所有 ConfigChanged 之后触发
+type ConfigReloaded struct {
+	Changed []string
+}
@@ -0,0 +1,77 @@
+package app
+
+import "errors"
+
+var (
+	ErrNotRunning = errors.New("app is not running")
+	ErrExited     = errors.New("service exited")
+)
+
+// ComponentStatus 单个组件的就绪和存活状态
+type ComponentStatus struct {
+	Name    string `json:"name"`
+	Ready   bool   `json:"ready"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Status 所有组件汇总后的就绪和存活状态
+type Status struct {
+	Ready      bool              `json:"ready"`
+	Healthy    bool              `json:"healthy"`
+	Components []ComponentStatus `json:"components"`
+}
+
+// Status 汇总组件状态, 只有 App 运行中且所有组件就绪时才就绪; 服务组件的 Start 提前退出视为不健康
+func (a *App) Status() (status Status) {
+	a.lock.RLock()
+	running := a.running
+	sorted := a.sorted
+	started := make(map[*node]bool, len(a.started))
+	for _, nd := range a.started {
+		started[nd] = true
+	}
+	a.lock.RUnlock()
+
+	status.Ready = running
+	status.Healthy = true
+	for _, nd := range sorted {
+		cs := ComponentStatus{
+			Name:    nd.name,
+			Ready:   running,
+			Healthy: true,
+		}
+		var errs []error
+		if !running {
+			errs = append(errs, ErrNotRunning)
+		}
+		if started[nd] {
+			select {
+			case <-nd.done:
+				cs.Ready = false
+				cs.Healthy = false
+				errs = append(errs, ErrExited)
+			default:
+			}
+		}
+		if r, ok := nd.component.(Readiness); ok && cs.Ready {
+			if err := r.Ready(); err != nil {
+				cs.Ready = false
+				errs = append(errs, err)
+			}
+		}
+		if l, ok := nd.component.(Liveness); ok && cs.Healthy {
+			if err := l.Healthy(); err != nil {
+				cs.Healthy = false
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			cs.Error = errors.Join(errs...).Error()
+		}
+		status.Ready = status.Ready && cs.Ready
+		status.Healthy = status.Healthy && cs.Healthy
+		status.Components = append(status.Components, cs)
+	}
+	return
+}
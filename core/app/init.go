@@ -3,17 +3,12 @@ package app
 import (
 	"fmt"
 	"log/slog"
-	"os"
 
 	"github.com/spf13/viper"
 )
 
 func init() {
 	RegisterListener(func(event BeforeInit) (err error) {
-		opts := &slog.HandlerOptions{
-			AddSource: true,
-		}
-
 		c, err := Resolve[*viper.Viper]()
 		if err != nil {
 			err = fmt.Errorf("get viper when init slog failed: %w", err)
@@ -21,11 +16,15 @@ func init() {
 			return
 		}
 
-		if c.GetString("mode") == ModeLocal || c.GetBool("showDebugLog") {
-			opts.Level = slog.LevelDebug
-		}
-
-		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, opts)))
+		setLog(c)
 		return
 	})
+
+	// 日志级别随配置热更新
+	for _, key := range []string{"mode", "showDebugLog"} {
+		OnConfigChange(key, func(event ConfigChanged) (err error) {
+			setLogLevel(viper.GetViper())
+			return
+		})
+	}
 }
@@ -0,0 +1,287 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Phase 组件生命周期阶段
+type Phase string
+
+const (
+	PhaseInit Phase = "init"
+	PhaseBoot Phase = "boot"
+	// PhaseStart 对实现了 Readiness 的组件, 启动后在超时时间内等待其就绪, 再启动依赖它的组件
+	PhaseStart Phase = "start"
+	PhaseStop  Phase = "stop"
+)
+
+var ErrTimeout = errors.New("timeout")
+
+var defaultTimeouts = map[Phase]time.Duration{
+	PhaseInit:  30 * time.Second,
+	PhaseBoot:  5 * time.Minute,
+	PhaseStart: 30 * time.Second,
+	PhaseStop:  15 * time.Second,
+}
+
+// ComponentError 组件在某个阶段执行失败或超时
+type ComponentError struct {
+	Component string
+	Phase     Phase
+	Err       error
+}
+
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("component <%s> %s failed: %s", e.Component, e.Phase, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+type node struct {
+	component Component
+	name      string
+	index     int
+	priority  int
+	deps      []string
+	done      chan struct{} // Start协程退出时关闭
+	err       error         // Start协程返回的错误, done关闭后可读
+	stop      func()
+}
+
+// service 取组件的启动和停止方法, 旧的 Serviceable 在 Start 中 panic 时转换为错误
+func service(c Component) (serve func() error, stop func(), ok bool) {
+	switch x := c.(type) {
+	case ServiceableWithError:
+		serve, stop = x.Serve, x.Stop
+	case Serviceable:
+		serve, stop = func() error {
+			x.Start()
+			return nil
+		}, x.Stop
+	default:
+		return
+	}
+	ok = true
+	serve = recoverServe(serve)
+	return
+}
+
+func recoverServe(serve func() error) func() error {
+	return func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				if e, ok := r.(error); ok {
+					err = fmt.Errorf("panic: %w", e)
+				} else {
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}
+		}()
+		return serve()
+	}
+}
+
+func componentName(c Component) string {
+	if n, ok := c.(Named); ok && n.Name() != "" {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", c)
+}
+
+// sortComponents 按依赖关系对组件做拓扑排序, 无依赖关系的组件按优先级和注册顺序排序
+func sortComponents(components []Component, extraDeps map[string][]string) (sorted []*node, err error) {
+	nodes := make(map[string]*node, len(components))
+	all := make([]*node, 0, len(components))
+	for i, c := range components {
+		n := &node{
+			component: c,
+			name:      componentName(c),
+			index:     i,
+		}
+		if _, ok := nodes[n.name]; ok {
+			n.name = fmt.Sprintf("%s#%d", n.name, i)
+		}
+		if p, ok := c.(Prioritized); ok {
+			n.priority = p.Priority()
+		}
+		if d, ok := c.(Dependent); ok {
+			n.deps = append(n.deps, d.DependsOn()...)
+		}
+		nodes[n.name] = n
+		all = append(all, n)
+	}
+	for _, n := range all {
+		d, ok := n.component.(OptionalDependent)
+		if !ok {
+			continue
+		}
+		for _, dep := range d.OptionalDependsOn() {
+			if _, ok := nodes[dep]; ok {
+				n.deps = append(n.deps, dep)
+			}
+		}
+	}
+	for name, deps := range extraDeps {
+		n, ok := nodes[name]
+		if !ok {
+			return nil, fmt.Errorf("declare dependency for unknown component <%s>", name)
+		}
+		n.deps = append(n.deps, deps...)
+	}
+
+	inDegree := make(map[string]int, len(all))
+	dependents := make(map[string][]*node, len(all))
+	for _, n := range all {
+		for _, dep := range n.deps {
+			if _, ok := nodes[dep]; !ok {
+				return nil, fmt.Errorf("component <%s> depends on unknown component <%s>", n.name, dep)
+			}
+			inDegree[n.name]++
+			dependents[dep] = append(dependents[dep], n)
+		}
+	}
+
+	less := func(a, b *node) bool {
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+		return a.index < b.index
+	}
+
+	ready := make([]*node, 0, len(all))
+	for _, n := range all {
+		if inDegree[n.name] == 0 {
+			ready = append(ready, n)
+		}
+	}
+	sorted = make([]*node, 0, len(all))
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool { return less(ready[i], ready[j]) })
+		n := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, n)
+		for _, d := range dependents[n.name] {
+			inDegree[d.name]--
+			if inDegree[d.name] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	if len(sorted) < len(all) {
+		return nil, fmt.Errorf("circular component dependency: %s", findCycle(all, nodes, inDegree))
+	}
+	return
+}
+
+// findCycle 在拓扑排序剩余的组件中找出一条依赖环路, 用于错误报告
+func findCycle(all []*node, nodes map[string]*node, inDegree map[string]int) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(all))
+	var path []string
+	var dfs func(n *node) []string
+	dfs = func(n *node) []string {
+		state[n.name] = visiting
+		path = append(path, n.name)
+		for _, dep := range n.deps {
+			switch state[dep] {
+			case visiting:
+				for i, name := range path {
+					if name == dep {
+						return append(append([]string{}, path[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := dfs(nodes[dep]); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[n.name] = visited
+		return nil
+	}
+	for _, n := range all {
+		if inDegree[n.name] > 0 && state[n.name] == unvisited {
+			if cycle := dfs(n); cycle != nil {
+				return strings.Join(cycle, " -> ")
+			}
+		}
+	}
+	return "unknown"
+}
+
+func phaseTimeout(n *node, phase Phase, timeout time.Duration) time.Duration {
+	if t, ok := n.component.(PhaseTimeouter); ok {
+		if d := t.Timeout(phase); d > 0 {
+			return d
+		}
+	}
+	return timeout
+}
+
+// runPhase 在超时时间内执行组件某个阶段的方法, timeout为0时不限制
+func runPhase(n *node, phase Phase, timeout time.Duration, f func() error) (err error) {
+	timeout = phaseTimeout(n, phase, timeout)
+	if timeout <= 0 {
+		err = f()
+	} else {
+		done := make(chan error, 1)
+		go func() {
+			done <- f()
+		}()
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case err = <-done:
+		case <-timer.C:
+			err = fmt.Errorf("%w after %s", ErrTimeout, timeout)
+		}
+	}
+
+	if err != nil {
+		err = &ComponentError{Component: n.name, Phase: phase, Err: err}
+	}
+	return
+}
+
+// waitReady 轮询组件的就绪状态, 直到就绪、Start提前退出或超时
+func waitReady(n *node, r Readiness, timeout time.Duration) (err error) {
+	timeout = phaseTimeout(n, PhaseStart, timeout)
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if err = r.Ready(); err == nil {
+			return
+		}
+		select {
+		case <-n.done:
+			if n.err != nil {
+				err = n.err
+			}
+			err = fmt.Errorf("exited before ready: %w", err)
+		case <-deadline:
+			err = fmt.Errorf("%w after %s waiting ready: %w", ErrTimeout, timeout, err)
+		case <-ticker.C:
+			continue
+		}
+		return &ComponentError{Component: n.name, Phase: PhaseStart, Err: err}
+	}
+}
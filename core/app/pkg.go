@@ -1,6 +1,8 @@
 package app
 
 import (
+	"context"
+
 	"github.com/goslacker/slacker/core/container"
 	"github.com/goslacker/slacker/core/eventbus"
 )
@@ -26,8 +28,13 @@ func RegisterComponent(components ...Component) {
 func Run() (n int, err error) {
 	return Default().Run()
 }
-func Shutdown() {
-	Default().Shutdown()
+func Shutdown() error {
+	return Default().Shutdown()
+}
+
+// DependsOn 为默认 App 中的组件声明依赖
+func DependsOn(component string, dependencies ...string) {
+	Default().DependsOn(component, dependencies...)
 }
 func RunAndWait() (err error) {
 	return Default().RunAndWait()
@@ -45,6 +52,21 @@ func MustResolve[T any]() (result T) {
 	return container.MustResolve[T]()
 }
 
+// Scope 创建请求或协程级别的作用域子容器, 见 container.Container.Scope
+func Scope(ctx context.Context) (context.Context, *container.Container) {
+	return container.Scope(ctx)
+}
+
+// BindContext 绑定到 ctx 中的作用域子容器
+func BindContext[T any](ctx context.Context, providerOrInstance any, sets ...func(*container.BindOpts)) (err error) {
+	return container.BindContext[T](ctx, providerOrInstance, sets...)
+}
+
+// ResolveContext 从 ctx 中的作用域子容器解析, 没有作用域时从默认容器解析
+func ResolveContext[T any](ctx context.Context, key ...string) (result T, err error) {
+	return container.ResolveContext[T](ctx, key...)
+}
+
 func Invoke(f any, opts ...func(*container.InvokeOpts)) (err error) {
 	return container.Invoke(f, opts...)
 }
@@ -60,6 +82,12 @@ func MustResolveDirectly[T any](provider any, opts ...func(*container.InvokeOpts
 func RegisterListener[T any](listeners ...eventbus.ListenerFunc[T]) {
 	eventbus.Register(listeners...)
 }
+
+// Subscribe 注册监听器, 返回的 Subscription 可以取消订阅
+func Subscribe[T any](listener eventbus.ListenerFunc[T], opts ...func(*eventbus.SubscribeOpts)) *eventbus.Subscription {
+	return eventbus.Subscribe(listener, opts...)
+}
+
 func Fire[T any](event T) (err error) {
 	return eventbus.Fire(event)
 }
@@ -67,3 +95,8 @@ func Fire[T any](event T) (err error) {
 func GetContainer() *container.Container {
 	return container.Default()
 }
+
+// GetStatus 默认 App 的组件就绪和存活状态
+func GetStatus() Status {
+	return Default().Status()
+}
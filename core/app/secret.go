@@ -0,0 +1,166 @@
+package app
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redacted = "******"
+
+// Secret 敏感配置, 如密码, 密钥, 打印日志, 格式化输出和序列化为json时都会隐藏内容, 需要明文时调用 Reveal
+type Secret string
+
+func (s Secret) String() string {
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return redacted
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// Reveal 返回明文
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// SecretResolver 根据引用读取敏感配置, 如 ${file:/run/secrets/db} 中的 /run/secrets/db
+type SecretResolver func(ref string) (string, error)
+
+var (
+	resolverLock sync.RWMutex
+	resolvers    = map[string]SecretResolver{
+		"file":   resolveFile,
+		"env":    resolveEnv,
+		"base64": resolveBase64,
+	}
+)
+
+// RegisterSecretResolver 注册配置引用的解析器, 加载配置时 ${scheme:ref} 会被替换为解析结果, 同名覆盖
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	resolverLock.Lock()
+	defer resolverLock.Unlock()
+	resolvers[scheme] = resolver
+}
+
+func resolveFile(ref string) (string, error) {
+	content, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+func resolveEnv(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("env <%s> is not set", ref)
+	}
+	return value, nil
+}
+
+func resolveBase64(ref string) (string, error) {
+	content, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+var refPattern = regexp.MustCompile(`\$\{([a-zA-Z0-9_]+):([^}]*)}`)
+
+// resolveRefs 替换字符串中的所有 ${scheme:ref}, 没有引用时 ok 为 false
+func resolveRefs(value string) (result string, ok bool, err error) {
+	if !strings.Contains(value, "${") {
+		return value, false, nil
+	}
+	result = refPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if err != nil {
+			return match
+		}
+		sub := refPattern.FindStringSubmatch(match)
+		resolverLock.RLock()
+		resolver, exists := resolvers[sub[1]]
+		resolverLock.RUnlock()
+		if !exists {
+			err = fmt.Errorf("unknown secret resolver <%s>", sub[1])
+			return match
+		}
+		ok = true
+		var resolved string
+		resolved, err = resolver(sub[2])
+		if err != nil {
+			err = fmt.Errorf("resolve <%s> failed: %w", match, err)
+		}
+		return resolved
+	})
+	return
+}
+
+// resolveSettings 解析配置中所有字符串的引用, 记录被解析的配置路径
+func resolveSettings(prefix string, settings map[string]any, secrets map[string]struct{}) error {
+	var errs []error
+	for k, v := range settings {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch value := v.(type) {
+		case map[string]any:
+			if err := resolveSettings(key, value, secrets); err != nil {
+				errs = append(errs, err)
+			}
+		case string:
+			resolved, ok, err := resolveRefs(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("config <%s>: %w", key, err))
+				continue
+			}
+			if ok {
+				settings[k] = resolved
+				secrets[key] = struct{}{}
+			}
+		case []any:
+			for i, item := range value {
+				s, isString := item.(string)
+				if !isString {
+					continue
+				}
+				resolved, ok, err := resolveRefs(s)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("config <%s.%d>: %w", key, i, err))
+					continue
+				}
+				if ok {
+					value[i] = resolved
+					secrets[key] = struct{}{}
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var sensitivePattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|salt|api_?key|private_?key|dsn|replicas)$`)
+
+// isSensitive 配置值来自引用或配置名看起来是敏感信息时, 输出配置时需要隐藏
+func isSensitive(key string, secrets map[string]struct{}) bool {
+	if _, ok := secrets[key]; ok {
+		return true
+	}
+	parts := strings.Split(key, ".")
+	return sensitivePattern.MatchString(parts[len(parts)-1])
+}
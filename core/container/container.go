@@ -7,11 +7,15 @@ import (
 	"sync"
 )
 
-var ErrNotFound = fmt.Errorf("not found")
+var (
+	ErrNotFound      = fmt.Errorf("not found")
+	ErrScopeRequired = fmt.Errorf("scoped binding must be resolved in a scope")
+)
 
 type provider struct {
 	Func      reflect.Value
 	Singleton bool
+	Scoped    bool
 }
 
 type InvokeOpts struct {
@@ -27,6 +31,7 @@ func WithParams(params map[int]any) func(*InvokeOpts) {
 
 type BindOpts struct {
 	Singleton bool
+	Scoped    bool
 	Key       string
 }
 
@@ -36,6 +41,13 @@ func NoSingleton() func(*BindOpts) {
 	}
 }
 
+// Scoped 每个作用域内单例, 只能在 Scope 创建的子容器中解析
+func Scoped() func(*BindOpts) {
+	return func(opts *BindOpts) {
+		opts.Scoped = true
+	}
+}
+
 func WithKey(key string) func(*BindOpts) {
 	return func(opts *BindOpts) {
 		opts.Key = key
@@ -53,6 +65,12 @@ type Container struct {
 	providers     map[reflect.Type]map[string]*provider
 	instances     map[reflect.Type]map[string]reflect.Value
 	instancesLock sync.Mutex
+	disposables   []disposable // 按创建顺序记录的实例, 关闭时逆序释放
+	closeLock     sync.Mutex
+
+	// 以下字段仅作用域子容器使用
+	parent    *Container
+	stopClose func() bool
 }
 
 func (c *Container) Bind(t reflect.Type, value reflect.Value, opts ...func(*BindOpts)) (err error) {
@@ -63,8 +81,9 @@ func (c *Container) Bind(t reflect.Type, value reflect.Value, opts ...func(*Bind
 		opt(options)
 	}
 
+	// 只清除同一个 key 的旧实例, 其他 key 的绑定不受影响
 	c.instancesLock.Lock()
-	delete(c.instances, t)
+	delete(c.instances[t], options.Key)
 	c.instancesLock.Unlock()
 	if canBindConsistent(t, value) {
 		err = c.bindInstance(t, value, options)
@@ -89,8 +108,9 @@ func (c *Container) bindInstance(t reflect.Type, value reflect.Value, options *B
 		group = make(map[string]reflect.Value)
 		c.instances[t] = group
 	}
-	c.instancesLock.Unlock()
 	group[options.Key] = value
+	c.instancesLock.Unlock()
+	c.track(disposable{value: value})
 
 	return
 }
@@ -104,6 +124,7 @@ func (c *Container) bindProvider(t reflect.Type, value reflect.Value, options *B
 	group[options.Key] = &provider{
 		Func:      value,
 		Singleton: options.Singleton,
+		Scoped:    options.Scoped,
 	}
 
 	return
@@ -171,30 +192,22 @@ func (c *Container) resolve(ctx context.Context, t reflect.Type, key string) (re
 		ctx = context.WithValue(ctx, "chain", newResolveChain())
 	}
 	chain := ctx.Value("chain").(*resolveChain)
+	pushed := false
 	defer func() {
-		if !ret.IsValid() || ret.IsNil() {
-			return
+		if ret.IsValid() && !ret.IsNil() {
+			chain.FillWait(t, ret)
 		}
-		chain.FillWait(t, ret)
-	}()
-
-	//resolve in instances
-	{
-		if group, ok := c.instances[t]; ok {
-			if ret, ok = group[key]; ok {
-				return
-			}
+		// 解析完成后出栈, 同一次解析中再次解析相同类型(如不同 key)时不会被误判为循环依赖
+		if pushed {
+			chain.Pop(t)
 		}
+	}()
 
-		// if no target found, resolve witch can be converted to target
-		for typ, group := range c.instances {
-			if typ.ConvertibleTo(t) {
-				var ok bool
-				if ret, ok = group[key]; ok {
-					ret = ret.Convert(t)
-					return
-				}
-			}
+	//resolve in instances, from current scope to root
+	for s := c; s != nil; s = s.parent {
+		var ok bool
+		if ret, ok = s.lookupInstance(t, key); ok {
+			return
 		}
 	}
 
@@ -205,51 +218,106 @@ func (c *Container) resolve(ctx context.Context, t reflect.Type, key string) (re
 			ret = reflect.Zero(t)
 			return
 		}
+		pushed = true
+
+		for s := c; s != nil; s = s.parent {
+			typ, prvd, ok := s.lookupProvider(t, key)
+			if !ok {
+				continue
+			}
+			ret, err = c.provide(ctx, s, typ, key, prvd)
+			if err != nil {
+				return
+			}
+			if typ != t {
+				ret = ret.Convert(t)
+			}
+			return
+		}
+	}
+	err = fmt.Errorf("type <%s> resolve failed: %w", t.String(), ErrNotFound)
+	return
+}
+
+func (c *Container) lookupInstance(t reflect.Type, key string) (ret reflect.Value, ok bool) {
+	c.instancesLock.Lock()
+	defer c.instancesLock.Unlock()
+	if group, exists := c.instances[t]; exists {
+		if ret, ok = group[key]; ok {
+			return
+		}
+	}
 
-		if group, ok := c.providers[t]; ok {
-			if value, ok := group[key]; ok {
-				var rets []reflect.Value
-				rets, err = c.invoke(ctx, value.Func)
-				if err != nil {
-					return
-				}
-				ret = rets[0]
-				if value.Singleton {
-					c.instancesLock.Lock()
-					if c.instances[t] == nil {
-						c.instances[t] = make(map[string]reflect.Value)
-					}
-					c.instances[t][key] = ret
-					c.instancesLock.Unlock()
-				}
+	// if no target found, resolve witch can be converted to target
+	for typ, group := range c.instances {
+		if typ.ConvertibleTo(t) {
+			if ret, ok = group[key]; ok {
+				ret = ret.Convert(t)
 				return
 			}
 		}
+	}
+	return
+}
 
-		// if no target found, resolve witch can be converted to target
-		for typ, group := range c.providers {
-			if typ.ConvertibleTo(t) {
-				if prvd, ok := group[key]; ok {
-					var rets []reflect.Value
-					rets, err = c.invoke(ctx, prvd.Func)
-					if err != nil {
-						return
-					}
-					ret = rets[0].Convert(t)
-					if prvd.Singleton {
-						c.instancesLock.Lock()
-						if c.instances[typ] == nil {
-							c.instances[typ] = make(map[string]reflect.Value)
-						}
-						c.instances[typ][key] = rets[0]
-						c.instancesLock.Unlock()
-					}
-					return
-				}
+func (c *Container) lookupProvider(t reflect.Type, key string) (typ reflect.Type, prvd *provider, ok bool) {
+	if group, exists := c.providers[t]; exists {
+		if prvd, ok = group[key]; ok {
+			return t, prvd, true
+		}
+	}
+
+	// if no target found, resolve witch can be converted to target
+	for typ, group := range c.providers {
+		if typ.ConvertibleTo(t) {
+			if prvd, ok = group[key]; ok {
+				return typ, prvd, true
 			}
 		}
 	}
-	err = fmt.Errorf("type <%s> resolve failed: %w", t.String(), ErrNotFound)
+	return
+}
+
+// provide 调用 owner 中绑定的 provider, 单例在 owner 中创建和缓存, 作用域单例和非单例在当前容器中创建, 作用域结束时释放
+func (c *Container) provide(ctx context.Context, owner *Container, typ reflect.Type, key string, prvd *provider) (ret reflect.Value, err error) {
+	target := c
+	if prvd.Singleton && !prvd.Scoped {
+		target = owner
+	}
+	if prvd.Scoped && c.parent == nil {
+		err = fmt.Errorf("type <%s> resolve failed: %w", typ.String(), ErrScopeRequired)
+		return
+	}
+
+	rets, err := target.invoke(ctx, prvd.Func)
+	if err != nil {
+		return
+	}
+	ret = rets[0]
+	cached := prvd.Singleton || prvd.Scoped
+	if cached {
+		// 先缓存再注入字段, 字段之间的循环引用可以解析到同一个实例
+		target.instancesLock.Lock()
+		if target.instances[typ] == nil {
+			target.instances[typ] = make(map[string]reflect.Value)
+		}
+		target.instances[typ][key] = ret
+		target.instancesLock.Unlock()
+	}
+	err = target.construct(ctx, ret)
+	if err != nil {
+		if cached {
+			target.instancesLock.Lock()
+			delete(target.instances[typ], key)
+			target.instancesLock.Unlock()
+		}
+		err = fmt.Errorf("construct <%s> failed: %w", typ, err)
+		return
+	}
+	// 根容器中的非单例由调用方管理
+	if cached || target.parent != nil {
+		target.track(disposable{t: typ, key: key, value: ret, cached: cached})
+	}
 	return
 }
 
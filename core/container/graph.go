@@ -0,0 +1,345 @@
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrMissingBinding = errors.New("missing binding")
+	ErrCircular       = errors.New("circular dependency")
+	ErrCaptive        = errors.New("singleton depends on scoped binding")
+)
+
+// DependencyError 依赖检查错误, Path 为从被检查的 provider 到出错类型的完整路径
+type DependencyError struct {
+	Err  error
+	Path []reflect.Type
+}
+
+func (e *DependencyError) Error() string {
+	names := make([]string, 0, len(e.Path))
+	for _, t := range e.Path {
+		names = append(names, t.String())
+	}
+	return fmt.Sprintf("%s: %s", e.Err, strings.Join(names, " -> "))
+}
+
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+type bindingID struct {
+	t   reflect.Type
+	key string
+}
+
+// Validate 检查所有已绑定 provider 的依赖, 一次返回所有缺失的绑定和无法解析的循环依赖
+//   - 非单例和作用域单例的依赖可能在作用域中才绑定, 不检查缺失
+//   - 单例之间通过结构体指针字段互相引用的循环依赖可以被解析, 不报错
+func (c *Container) Validate() error {
+	v := &validator{
+		c:       c,
+		state:   make(map[bindingID]int),
+		reports: make(map[string]struct{}),
+	}
+	for _, id := range c.providerIDs() {
+		if v.state[id] == 0 {
+			_, prvd, _ := c.findProvider(id.t, id.key)
+			v.visit(id, prvd, []reflect.Type{id.t})
+		}
+	}
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	c       *Container
+	state   map[bindingID]int // 0 未检查, 1 检查中, 2 已检查
+	stack   []bindingID
+	reports map[string]struct{}
+	errs    []error
+}
+
+func (v *validator) report(err *DependencyError) {
+	msg := err.Error()
+	if _, ok := v.reports[msg]; ok {
+		return
+	}
+	v.reports[msg] = struct{}{}
+	v.errs = append(v.errs, err)
+}
+
+func (v *validator) visit(id bindingID, prvd *provider, path []reflect.Type) {
+	v.state[id] = 1
+	v.stack = append(v.stack, id)
+	defer func() {
+		v.stack = v.stack[:len(v.stack)-1]
+		v.state[id] = 2
+	}()
+
+	for _, d := range prvd.dependencies() {
+		param := d.t
+		paramPath := append(append([]reflect.Type{}, path...), param)
+		if v.c.hasInstance(param, d.key) {
+			continue
+		}
+		typ, dep, ok := v.c.findProvider(param, d.key)
+		if !ok {
+			if prvd.Singleton && !prvd.Scoped {
+				v.report(&DependencyError{Err: ErrMissingBinding, Path: paramPath})
+			}
+			continue
+		}
+		if dep.Scoped && prvd.Singleton && !prvd.Scoped {
+			v.report(&DependencyError{Err: ErrCaptive, Path: paramPath})
+		}
+
+		depID := bindingID{t: typ, key: d.key}
+		switch v.state[depID] {
+		case 0:
+			v.visit(depID, dep, paramPath)
+		case 1:
+			if cycle := v.cycle(depID); !v.c.canBreak(cycle) {
+				types := make([]reflect.Type, 0, len(cycle)+1)
+				for _, item := range cycle {
+					types = append(types, item.t)
+				}
+				v.report(&DependencyError{Err: ErrCircular, Path: append(types, typ)})
+			}
+		}
+	}
+}
+
+// dependencies provider 的参数和返回的结构体中带 inject 标签的字段
+func (p *provider) dependencies() (deps []bindingID) {
+	fnType := p.Func.Type()
+	for i := 0; i < fnType.NumIn(); i++ {
+		deps = append(deps, bindingID{t: fnType.In(i)})
+	}
+	out := fnType.Out(0)
+	if out.Kind() != reflect.Pointer || out.Elem().Kind() != reflect.Struct {
+		return
+	}
+	out = out.Elem()
+	for i := 0; i < out.NumField(); i++ {
+		f := out.Field(i)
+		if key, ok := f.Tag.Lookup("inject"); ok && key != "-" {
+			deps = append(deps, bindingID{t: f.Type, key: key})
+		}
+	}
+	return
+}
+
+// cycle 从检查栈中截取以 id 开始的循环
+func (v *validator) cycle(id bindingID) []bindingID {
+	for i := len(v.stack) - 1; i >= 0; i-- {
+		if v.stack[i] == id {
+			return append([]bindingID{}, v.stack[i:]...)
+		}
+	}
+	return nil
+}
+
+// canBreak 循环中每个 provider 都是单例, 且其返回的结构体中有下一个依赖类型的字段时, 解析时可以先注入nil再回填
+func (c *Container) canBreak(cycle []bindingID) bool {
+	for i, id := range cycle {
+		next := cycle[(i+1)%len(cycle)]
+		_, prvd, ok := c.findProvider(id.t, id.key)
+		if !ok || !prvd.Singleton || prvd.Scoped {
+			return false
+		}
+		switch next.t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		default:
+			return false
+		}
+		out := prvd.Func.Type().Out(0)
+		for out.Kind() == reflect.Pointer {
+			out = out.Elem()
+		}
+		if out.Kind() != reflect.Struct || !hasFieldOfType(out, next.t) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasFieldOfType(t reflect.Type, fieldType reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type == fieldType {
+			return true
+		}
+	}
+	return false
+}
+
+// providerIDs 当前容器中绑定的所有 provider, 按类型排序以保证检查结果稳定
+func (c *Container) providerIDs() (ids []bindingID) {
+	for t, group := range c.providers {
+		for key := range group {
+			ids = append(ids, bindingID{t: t, key: key})
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].t.String() != ids[j].t.String() {
+			return ids[i].t.String() < ids[j].t.String()
+		}
+		return ids[i].key < ids[j].key
+	})
+	return
+}
+
+func (c *Container) hasInstance(t reflect.Type, key string) bool {
+	for s := c; s != nil; s = s.parent {
+		if _, ok := s.lookupInstance(t, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Container) findProvider(t reflect.Type, key string) (typ reflect.Type, prvd *provider, ok bool) {
+	for s := c; s != nil; s = s.parent {
+		if typ, prvd, ok = s.lookupProvider(t, key); ok {
+			return
+		}
+	}
+	return
+}
+
+// GraphNode 容器中的一个绑定
+type GraphNode struct {
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	Key          string   `json:"key,omitempty"`
+	Kind         string   `json:"kind"` // instance, provider 或 missing
+	Singleton    bool     `json:"singleton"`
+	Scoped       bool     `json:"scoped"`
+	Created      bool     `json:"created"` // 单例是否已经创建
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// Graph 容器中的绑定及其依赖关系
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+}
+
+const (
+	NodeInstance = "instance"
+	NodeProvider = "provider"
+	NodeMissing  = "missing"
+)
+
+// Graph 导出当前容器中的绑定及其依赖关系, 依赖指向实际会被使用的绑定, 没有绑定的依赖作为 missing 节点
+func (c *Container) Graph() (g Graph) {
+	nodes := make(map[string]*GraphNode)
+
+	c.instancesLock.Lock()
+	for t, group := range c.instances {
+		for key := range group {
+			id := nodeID(t, key)
+			nodes[id] = &GraphNode{ID: id, Type: t.String(), Key: key, Kind: NodeInstance, Singleton: true, Created: true}
+		}
+	}
+	c.instancesLock.Unlock()
+
+	for _, bid := range c.providerIDs() {
+		prvd := c.providers[bid.t][bid.key]
+		id := nodeID(bid.t, bid.key)
+		node := &GraphNode{ID: id, Type: bid.t.String(), Key: bid.key, Kind: NodeProvider, Singleton: prvd.Singleton, Scoped: prvd.Scoped}
+		// 单例创建后会缓存为实例
+		if existing, ok := nodes[id]; ok && existing.Kind == NodeInstance {
+			node.Created = true
+		}
+		for _, d := range prvd.dependencies() {
+			node.Dependencies = append(node.Dependencies, c.dependencyID(d.t, d.key, nodes))
+		}
+		nodes[id] = node
+	}
+
+	for _, node := range nodes {
+		g.Nodes = append(g.Nodes, *node)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	return
+}
+
+func (c *Container) dependencyID(t reflect.Type, key string, nodes map[string]*GraphNode) string {
+	for s := c; s != nil; s = s.parent {
+		s.instancesLock.Lock()
+		_, ok := s.instances[t][key]
+		s.instancesLock.Unlock()
+		if ok {
+			return nodeID(t, key)
+		}
+		if typ, _, ok := s.lookupProvider(t, key); ok {
+			return nodeID(typ, key)
+		}
+		s.instancesLock.Lock()
+		for typ, group := range s.instances {
+			if _, ok := group[key]; ok && typ.ConvertibleTo(t) {
+				s.instancesLock.Unlock()
+				return nodeID(typ, key)
+			}
+		}
+		s.instancesLock.Unlock()
+	}
+	id := nodeID(t, key)
+	if _, ok := nodes[id]; !ok {
+		nodes[id] = &GraphNode{ID: id, Type: t.String(), Key: key, Kind: NodeMissing}
+	}
+	return id
+}
+
+func nodeID(t reflect.Type, key string) string {
+	if key == "" {
+		return t.String()
+	}
+	return t.String() + "#" + key
+}
+
+// JSON 以json格式导出
+func (g Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// DOT 以 graphviz dot 格式导出, 单例为实线框, 非单例为虚线框, 作用域单例为圆角框, 缺失的绑定为红色
+func (g Graph) DOT() string {
+	b := &strings.Builder{}
+	b.WriteString("digraph container {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		var attrs []string
+		label := node.Type
+		if node.Key != "" {
+			label += "\\nkey: " + node.Key
+		}
+		switch {
+		case node.Kind == NodeMissing:
+			attrs = append(attrs, "color=red", "fontcolor=red")
+		case node.Scoped:
+			attrs = append(attrs, `style="rounded"`)
+			label += "\\nscoped"
+		case !node.Singleton:
+			attrs = append(attrs, "style=dashed")
+			label += "\\ntransient"
+		case node.Created:
+			label += "\\ncreated"
+		}
+		attrs = append([]string{fmt.Sprintf("label=%q", label)}, attrs...)
+		fmt.Fprintf(b, "  %q [%s];\n", node.ID, strings.Join(attrs, ", "))
+	}
+	for _, node := range g.Nodes {
+		for _, dep := range node.Dependencies {
+			fmt.Fprintf(b, "  %q -> %q;\n", node.ID, dep)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
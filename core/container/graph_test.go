@@ -0,0 +1,99 @@
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type graphDB struct{}
+
+type graphRepo struct {
+	db *graphDB
+}
+
+type graphService struct {
+	repo *graphRepo
+}
+
+type graphA struct{ b *graphB }
+type graphB struct{ name string }
+
+func TestContainer_Validate(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		c := NewContainer()
+		require.NoError(t, bindTo[*graphDB](c, &graphDB{}))
+		require.NoError(t, bindTo[*graphRepo](c, func(db *graphDB) *graphRepo { return &graphRepo{db: db} }))
+		require.NoError(t, bindTo[*graphService](c, func(repo *graphRepo) *graphService { return &graphService{repo: repo} }))
+		require.NoError(t, c.Validate())
+	})
+
+	t.Run("missing binding with path", func(t *testing.T) {
+		c := NewContainer()
+		require.NoError(t, bindTo[*graphRepo](c, func(db *graphDB) *graphRepo { return &graphRepo{db: db} }))
+		require.NoError(t, bindTo[*graphService](c, func(repo *graphRepo) *graphService { return &graphService{repo: repo} }))
+
+		err := c.Validate()
+		require.ErrorIs(t, err, ErrMissingBinding)
+		require.EqualError(t, err, "missing binding: *container.graphRepo -> *container.graphDB")
+		var de *DependencyError
+		require.True(t, errors.As(err, &de))
+		require.Len(t, de.Path, 2)
+	})
+
+	t.Run("missing binding of transient is ignored", func(t *testing.T) {
+		c := NewContainer()
+		require.NoError(t, bindTo[*graphRepo](c, func(db *graphDB) *graphRepo { return &graphRepo{db: db} }, NoSingleton()))
+		require.NoError(t, c.Validate())
+	})
+
+	t.Run("breakable cycle", func(t *testing.T) {
+		c := NewContainer()
+		require.NoError(t, bindTo[*Struct1](c, NewStruct1))
+		require.NoError(t, bindTo[*Struct2](c, NewStruct2))
+		require.NoError(t, c.Validate())
+	})
+
+	t.Run("unbreakable cycle", func(t *testing.T) {
+		c := NewContainer()
+		require.NoError(t, bindTo[*graphA](c, func(b *graphB) *graphA { return &graphA{b: b} }))
+		require.NoError(t, bindTo[*graphB](c, func(a *graphA) *graphB { return &graphB{name: "b"} }))
+		err := c.Validate()
+		require.ErrorIs(t, err, ErrCircular)
+		require.EqualError(t, err, "circular dependency: *container.graphA -> *container.graphB -> *container.graphA")
+	})
+
+	t.Run("singleton depends on scoped", func(t *testing.T) {
+		c := NewContainer()
+		require.NoError(t, bindTo[*graphDB](c, func() *graphDB { return &graphDB{} }, Scoped()))
+		require.NoError(t, bindTo[*graphRepo](c, func(db *graphDB) *graphRepo { return &graphRepo{db: db} }))
+		require.ErrorIs(t, c.Validate(), ErrCaptive)
+	})
+}
+
+func TestContainer_Graph(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, bindTo[*graphRepo](c, func(db *graphDB) *graphRepo { return &graphRepo{db: db} }))
+	require.NoError(t, bindTo[*graphService](c, func(repo *graphRepo) *graphService { return &graphService{repo: repo} }, WithKey("v2"), NoSingleton()))
+	require.NoError(t, bindTo[*graphB](c, &graphB{}))
+
+	g := c.Graph()
+	require.Equal(t, []GraphNode{
+		{ID: "*container.graphB", Type: "*container.graphB", Kind: NodeInstance, Singleton: true, Created: true},
+		{ID: "*container.graphDB", Type: "*container.graphDB", Kind: NodeMissing},
+		{ID: "*container.graphRepo", Type: "*container.graphRepo", Kind: NodeProvider, Singleton: true, Dependencies: []string{"*container.graphDB"}},
+		{ID: "*container.graphService#v2", Type: "*container.graphService", Key: "v2", Kind: NodeProvider, Dependencies: []string{"*container.graphRepo"}},
+	}, g.Nodes)
+
+	content, err := g.JSON()
+	require.NoError(t, err)
+	var decoded Graph
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	require.Equal(t, g, decoded)
+
+	dot := g.DOT()
+	require.Contains(t, dot, `"*container.graphService#v2" -> "*container.graphRepo";`)
+	require.Contains(t, dot, `"*container.graphDB" [label="*container.graphDB", color=red, fontcolor=red];`)
+}
@@ -0,0 +1,121 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// PostConstructor provider 创建实例并注入字段后调用 PostConstruct, 返回错误时解析失败
+type PostConstructor interface {
+	PostConstruct() error
+}
+
+type disposable struct {
+	t      reflect.Type
+	key    string
+	value  reflect.Value
+	cached bool // 是否为 provider 创建并缓存的单例, 关闭后从容器中移除
+}
+
+func (c *Container) track(d disposable) {
+	c.instancesLock.Lock()
+	defer c.instancesLock.Unlock()
+	c.disposables = append(c.disposables, d)
+}
+
+// construct 注入带 inject 标签的字段, 然后调用 PostConstruct
+func (c *Container) construct(ctx context.Context, v reflect.Value) (err error) {
+	err = c.injectFields(ctx, v)
+	if err != nil {
+		return
+	}
+	if !isNil(v) {
+		if pc, ok := v.Interface().(PostConstructor); ok {
+			err = pc.PostConstruct()
+		}
+	}
+	return
+}
+
+// injectFields 为结构体指针中带 inject 标签的字段解析依赖, 标签值为绑定的 key, 如 `inject:""` 或 `inject:"read"`
+func (c *Container) injectFields(ctx context.Context, v reflect.Value) (err error) {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	elem := v.Elem()
+	for i := 0; i < elem.NumField(); i++ {
+		f := elem.Type().Field(i)
+		key, ok := f.Tag.Lookup("inject")
+		if !ok || key == "-" {
+			continue
+		}
+		var dep reflect.Value
+		dep, err = c.resolve(ctx, f.Type, key)
+		if err != nil {
+			return fmt.Errorf("inject field <%s.%s> failed: %w", elem.Type(), f.Name, err)
+		}
+		field := elem.Field(i)
+		// 未导出字段也可以注入
+		reflect.NewAt(f.Type, field.Addr().UnsafePointer()).Elem().Set(dep)
+	}
+	return
+}
+
+// Inject 为已创建的结构体指针注入带 inject 标签的字段并调用 PostConstruct
+func (c *Container) Inject(target any) error {
+	ctx := context.WithValue(context.Background(), "chain", newResolveChain())
+	return c.construct(ctx, reflect.ValueOf(target))
+}
+
+// Close 按创建的逆序关闭容器中实现了 io.Closer 的实例, 包括绑定的实例和 provider 创建的单例, 单例关闭后会从容器中移除, 再次解析时重新创建
+// 可以多次调用, 每次只关闭上次关闭之后创建的实例
+func (c *Container) Close() error {
+	c.closeLock.Lock()
+	defer c.closeLock.Unlock()
+	if c.stopClose != nil {
+		c.stopClose()
+	}
+
+	c.instancesLock.Lock()
+	disposables := c.disposables
+	c.disposables = nil
+	for _, d := range disposables {
+		if d.cached {
+			delete(c.instances[d.t], d.key)
+		}
+	}
+	c.instancesLock.Unlock()
+
+	var errs []error
+	for i := len(disposables) - 1; i >= 0; i-- {
+		v := disposables[i].value
+		if isNil(v) {
+			continue
+		}
+		closer, ok := v.Interface().(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close <%s> failed: %w", v.Type(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isNil(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package container
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type hookLog struct {
+	events []string
+}
+
+type hookDB struct {
+	name string
+	log  *hookLog
+}
+
+func (d *hookDB) Close() error {
+	d.log.events = append(d.log.events, "close "+d.name)
+	return nil
+}
+
+type hookCache struct {
+	log *hookLog
+}
+
+func (c *hookCache) Close() error {
+	c.log.events = append(c.log.events, "close cache")
+	return errors.New("cache busy")
+}
+
+type hookService struct {
+	Primary *hookDB `inject:""`
+	replica *hookDB `inject:"replica"`
+	cache   *hookCache
+	skip    *hookDB `inject:"-"`
+	log     *hookLog
+}
+
+func (s *hookService) PostConstruct() error {
+	if s.Primary == nil || s.replica == nil {
+		return errors.New("not injected")
+	}
+	s.log.events = append(s.log.events, "post construct")
+	return nil
+}
+
+func (s *hookService) Close() error {
+	s.log.events = append(s.log.events, "close service")
+	return nil
+}
+
+func TestContainer_Lifecycle(t *testing.T) {
+	log := &hookLog{}
+	c := NewContainer()
+	require.NoError(t, bindTo[*hookLog](c, log))
+	require.NoError(t, bindTo[*hookDB](c, func(log *hookLog) *hookDB {
+		log.events = append(log.events, "create primary")
+		return &hookDB{name: "primary", log: log}
+	}))
+	require.NoError(t, bindTo[*hookDB](c, func(log *hookLog) *hookDB {
+		log.events = append(log.events, "create replica")
+		return &hookDB{name: "replica", log: log}
+	}, WithKey("replica")))
+	require.NoError(t, bindTo[*hookCache](c, &hookCache{log: log}))
+	require.NoError(t, bindTo[*hookService](c, func(log *hookLog, cache *hookCache) *hookService {
+		return &hookService{log: log, cache: cache}
+	}))
+	require.NoError(t, c.Validate())
+
+	t.Run("inject fields and post construct", func(t *testing.T) {
+		v, err := c.Resolve(reflect.TypeOf((*hookService)(nil)), "")
+		require.NoError(t, err)
+		s := v.Interface().(*hookService)
+		require.Equal(t, "primary", s.Primary.name)
+		require.Equal(t, "replica", s.replica.name)
+		require.Nil(t, s.skip)
+		require.Equal(t, []string{"create primary", "create replica", "post construct"}, log.events)
+	})
+
+	t.Run("close in reverse creation order", func(t *testing.T) {
+		log.events = nil
+		err := c.Close()
+		require.ErrorContains(t, err, "cache busy")
+		require.Equal(t, []string{"close service", "close replica", "close primary", "close cache"}, log.events)
+
+		// 单例关闭后重新创建
+		log.events = nil
+		require.NoError(t, c.Close())
+		require.Empty(t, log.events)
+		v, err := c.Resolve(reflect.TypeOf((*hookService)(nil)), "")
+		require.NoError(t, err)
+		require.NotNil(t, v.Interface().(*hookService).Primary)
+		require.Equal(t, []string{"create primary", "create replica", "post construct"}, log.events)
+	})
+
+	t.Run("post construct failure", func(t *testing.T) {
+		c := NewContainer()
+		require.NoError(t, bindTo[*hookLog](c, log))
+		require.NoError(t, bindTo[*hookCache](c, &hookCache{log: log}))
+		require.NoError(t, bindTo[*hookService](c, func(log *hookLog, cache *hookCache) *hookService {
+			return &hookService{log: log, cache: cache}
+		}))
+		_, err := c.Resolve(reflect.TypeOf((*hookService)(nil)), "")
+		require.ErrorContains(t, err, "inject field <container.hookService.Primary> failed")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		err = c.Validate()
+		require.ErrorIs(t, err, ErrMissingBinding)
+		require.ErrorContains(t, err, "*container.hookService -> *container.hookDB")
+	})
+
+	t.Run("inject existing", func(t *testing.T) {
+		s := &hookService{log: log}
+		require.NoError(t, c.Inject(s))
+		require.Equal(t, "replica", s.replica.name)
+	})
+}
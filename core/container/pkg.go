@@ -1,6 +1,7 @@
 package container
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"sync"
@@ -90,3 +91,36 @@ func MustResolveDirectly[T any](provider any, opts ...func(*InvokeOpts)) (result
 	}
 	return
 }
+
+// Scope 基于默认容器创建作用域子容器
+func Scope(ctx context.Context) (context.Context, *Container) {
+	return Default().Scope(ctx)
+}
+
+// BindContext 绑定到 context 中的作用域子容器, 没有作用域时返回 ErrNoScope
+func BindContext[T any](ctx context.Context, providerOrInstance any, sets ...func(*BindOpts)) (err error) {
+	scope, ok := ScopeFromContext(ctx)
+	if !ok {
+		return ErrNoScope
+	}
+	return scope.Bind(reflect.TypeOf((*T)(nil)).Elem(), reflect.ValueOf(providerOrInstance), sets...)
+}
+
+// ResolveContext 从 context 中的作用域子容器解析, 没有作用域时从默认容器解析
+func ResolveContext[T any](ctx context.Context, key ...string) (result T, err error) {
+	var k string
+	if len(key) > 0 {
+		k = key[0]
+	}
+	res, err := FromContext(ctx).Resolve(reflect.TypeOf((*T)(nil)).Elem(), k)
+	if err != nil {
+		return
+	}
+	result = res.Interface().(T)
+	return
+}
+
+// Inject 使用默认容器为结构体指针注入带 inject 标签的字段
+func Inject(target any) error {
+	return Default().Inject(target)
+}
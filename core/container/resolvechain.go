@@ -67,3 +67,13 @@ func (r *resolveChain) FillWait(t reflect.Type, v reflect.Value) {
 		}
 	}
 }
+
+// Pop 移除最近一次入栈的 t, 已经被 FillWait 移除时忽略
+func (r *resolveChain) Pop(t reflect.Type) {
+	for i := len(r.chain) - 1; i >= 0; i-- {
+		if r.chain[i] == t {
+			r.chain = append(r.chain[:i], r.chain[i+1:]...)
+			return
+		}
+	}
+}
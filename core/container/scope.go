@@ -0,0 +1,47 @@
+package container
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoScope = errors.New("no scope in context")
+
+type scopeKey struct{}
+
+// Scope 创建继承当前容器绑定的子容器, 并放入返回的 context 中
+//   - 子容器中 Bind 的内容只在子容器中可见, 如当前用户, 租户数据库连接
+//   - 通过 Scoped 绑定的 provider 在每个子容器中只创建一次
+//   - 单例仍由绑定它的容器创建和持有, 所有子容器共享
+//
+// 调用 Close 或 ctx 结束时, 子容器按创建的逆序关闭其中实现了 io.Closer 的实例
+func (c *Container) Scope(ctx context.Context) (context.Context, *Container) {
+	scope := NewContainer()
+	scope.parent = c
+	scope.stopClose = context.AfterFunc(ctx, func() {
+		_ = scope.Close()
+	})
+	return context.WithValue(ctx, scopeKey{}, scope), scope
+}
+
+// Parent 返回父容器, 非作用域子容器返回nil
+func (c *Container) Parent() *Container {
+	return c.parent
+}
+
+// FromContext 返回 context 中的作用域子容器, 没有时返回默认容器
+func FromContext(ctx context.Context) *Container {
+	if scope, ok := ScopeFromContext(ctx); ok {
+		return scope
+	}
+	return Default()
+}
+
+// ScopeFromContext 返回 context 中的作用域子容器
+func ScopeFromContext(ctx context.Context) (scope *Container, ok bool) {
+	if ctx == nil {
+		return
+	}
+	scope, ok = ctx.Value(scopeKey{}).(*Container)
+	return
+}
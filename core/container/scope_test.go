@@ -0,0 +1,115 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type scopeUser struct {
+	Name string
+}
+
+type scopeConn struct {
+	name   string
+	closed *[]string
+}
+
+func (c *scopeConn) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return nil
+}
+
+type scopeRepo struct {
+	conn *scopeConn
+	user *scopeUser
+}
+
+type scopeConfig struct {
+	DSN string
+}
+
+func bindTo[T any](c *Container, providerOrInstance any, sets ...func(*BindOpts)) error {
+	return c.Bind(reflect.TypeOf((*T)(nil)).Elem(), reflect.ValueOf(providerOrInstance), sets...)
+}
+
+func TestContainer_Scope(t *testing.T) {
+	var closed []string
+	var created int
+	c := NewContainer()
+	require.NoError(t, bindTo[*scopeConfig](c, &scopeConfig{DSN: "test"}))
+	require.NoError(t, bindTo[*scopeConn](c, func(conf *scopeConfig) *scopeConn {
+		created++
+		return &scopeConn{name: "conn", closed: &closed}
+	}, Scoped()))
+	require.NoError(t, bindTo[*scopeRepo](c, func(conn *scopeConn, user *scopeUser) *scopeRepo {
+		return &scopeRepo{conn: conn, user: user}
+	}, NoSingleton()))
+
+	t.Run("inherit and hold scoped singleton", func(t *testing.T) {
+		closed, created = nil, 0
+		ctx, scope := c.Scope(context.Background())
+		require.NoError(t, BindContext[*scopeUser](ctx, &scopeUser{Name: "tom"}))
+
+		repo, err := ResolveContext[*scopeRepo](ctx)
+		require.NoError(t, err)
+		require.Equal(t, "tom", repo.user.Name)
+		repo2, err := ResolveContext[*scopeRepo](ctx)
+		require.NoError(t, err)
+		require.NotSame(t, repo, repo2)
+		require.Same(t, repo.conn, repo2.conn)
+		require.Equal(t, 1, created)
+
+		conf, err := ResolveContext[*scopeConfig](ctx)
+		require.NoError(t, err)
+		require.Equal(t, "test", conf.DSN)
+
+		require.NoError(t, scope.Close())
+		require.NoError(t, scope.Close())
+		require.Equal(t, []string{"conn"}, closed)
+	})
+
+	t.Run("scopes are isolated", func(t *testing.T) {
+		closed, created = nil, 0
+		ctx1, scope1 := c.Scope(context.Background())
+		ctx2, scope2 := c.Scope(context.Background())
+		defer scope1.Close()
+		defer scope2.Close()
+
+		conn1, err := ResolveContext[*scopeConn](ctx1)
+		require.NoError(t, err)
+		conn2, err := ResolveContext[*scopeConn](ctx2)
+		require.NoError(t, err)
+		require.NotSame(t, conn1, conn2)
+
+		_, err = ResolveContext[*scopeUser](ctx2)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("close when context done", func(t *testing.T) {
+		closed = nil
+		parent, cancel := context.WithCancel(context.Background())
+		ctx, scope := c.Scope(parent)
+		_, err := ResolveContext[*scopeConn](ctx)
+		require.NoError(t, err)
+
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		// 已经由 ctx 结束触发关闭, 再次调用只等待其完成
+		require.NoError(t, scope.Close())
+		require.Equal(t, []string{"conn"}, closed)
+	})
+
+	t.Run("scoped requires scope", func(t *testing.T) {
+		_, err := c.Resolve(reflect.TypeOf((*scopeConn)(nil)), "")
+		require.True(t, errors.Is(err, ErrScopeRequired))
+	})
+
+	t.Run("bind without scope", func(t *testing.T) {
+		require.ErrorIs(t, BindContext[*scopeUser](context.Background(), &scopeUser{}), ErrNoScope)
+	})
+}
@@ -33,9 +33,28 @@ type Offset int
 
 type Migrator interface {
 	RegisterMigrates(...any) error
+	// Migrate 执行所有未执行的迁移
 	Migrate() error
+	// Up 向上执行 stepNum 个迁移, 小于等于 0 时执行所有
 	Up(stepNum int) error
+	// Down 回滚 stepNum 个迁移, stepNum 必须大于 0
 	Down(stepNum int) error
+	// DownAll 回滚所有迁移
+	DownAll() error
+	// Goto 迁移到指定版本, 按需向上执行或回滚
+	Goto(version uint) error
+	// Force 强制设置当前版本并清除 dirty 状态, 用于迁移失败后手动修复, -1 表示没有版本
+	Force(version int) error
+	// Status 所有迁移的执行状态
+	Status() ([]MigrationStatus, error)
+}
+
+// MigrationStatus 一个迁移的执行状态, Dirty 表示该版本执行失败需要手动修复
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+	Dirty   bool
 }
 
 type Sort string
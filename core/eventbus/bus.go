@@ -0,0 +1,284 @@
+package eventbus
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ErrorPolicy 同步监听器返回错误时的处理方式
+type ErrorPolicy int
+
+const (
+	StopOnError     ErrorPolicy = iota // 遇到第一个错误停止, 返回该错误
+	ContinueOnError                    // 调用所有监听器, 合并返回所有错误
+	IgnoreError                        // 调用所有监听器, 错误交给 ErrorHandler 处理, 不返回
+)
+
+// ErrorHandler 处理异步监听器和 IgnoreError 策略下的错误
+type ErrorHandler func(event any, err error)
+
+type BusOpts struct {
+	Policy       ErrorPolicy
+	Workers      int // 异步投递的 worker 数量, 默认为 cpu 数量
+	QueueSize    int // 异步投递的队列长度, 队列满时 Fire 阻塞, 默认 1024
+	ErrorHandler ErrorHandler
+}
+
+func WithErrorPolicy(policy ErrorPolicy) func(*BusOpts) {
+	return func(opts *BusOpts) {
+		opts.Policy = policy
+	}
+}
+
+func WithWorkers(workers int, queueSize int) func(*BusOpts) {
+	return func(opts *BusOpts) {
+		opts.Workers = workers
+		opts.QueueSize = queueSize
+	}
+}
+
+func WithErrorHandler(handler ErrorHandler) func(*BusOpts) {
+	return func(opts *BusOpts) {
+		opts.ErrorHandler = handler
+	}
+}
+
+type SubscribeOpts struct {
+	Priority int // 优先级高的先调用, 相同优先级按注册顺序
+	Async    bool
+}
+
+func WithPriority(priority int) func(*SubscribeOpts) {
+	return func(opts *SubscribeOpts) {
+		opts.Priority = priority
+	}
+}
+
+// Async 监听器在 worker 中异步调用, 错误交给 ErrorHandler 处理
+func Async() func(*SubscribeOpts) {
+	return func(opts *SubscribeOpts) {
+		opts.Async = true
+	}
+}
+
+// Subscription 注册的监听器, 用于取消订阅
+type Subscription struct {
+	bus       *Bus
+	seq       uint64
+	eventType reflect.Type
+	priority  int
+	async     bool
+	call      func(event any) error
+}
+
+// Unsubscribe 取消订阅, 可以多次调用
+func (s *Subscription) Unsubscribe() {
+	s.bus.remove(s)
+}
+
+// match 监听的类型为接口时, 接收所有实现了该接口的事件
+func (s *Subscription) match(t reflect.Type) bool {
+	if s.eventType == t {
+		return true
+	}
+	return s.eventType.Kind() == reflect.Interface && t.Implements(s.eventType)
+}
+
+func (s *Subscription) invoke(event any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("listener of <%s> panic: %v", s.eventType, r)
+		}
+	}()
+	return s.call(event)
+}
+
+// Bus 线程安全的事件总线
+type Bus struct {
+	opts    BusOpts
+	lock    sync.RWMutex
+	seq     uint64
+	subs    []*Subscription // 按优先级从高到低, 注册顺序排列
+	start   sync.Once
+	tasks   chan func()
+	pending sync.WaitGroup
+	state   sync.RWMutex
+	closed  bool
+}
+
+func NewBus(opts ...func(*BusOpts)) *Bus {
+	b := &Bus{
+		opts: BusOpts{
+			Workers:   runtime.NumCPU(),
+			QueueSize: 1024,
+			ErrorHandler: func(event any, err error) {
+				slog.Error("handle event failed", "event", reflect.TypeOf(event).String(), "error", err)
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(&b.opts)
+	}
+	if b.opts.Workers <= 0 {
+		b.opts.Workers = 1
+	}
+	if b.opts.QueueSize < 0 {
+		b.opts.QueueSize = 0
+	}
+	return b
+}
+
+// Subscribe 注册监听器, listener 必须为 func(T) error, T 可以为接口
+func (b *Bus) Subscribe(listener any, opts ...func(*SubscribeOpts)) (sub *Subscription, err error) {
+	v := reflect.ValueOf(listener)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("listener must be a func, got <%T>", listener)
+	}
+	t := v.Type()
+	if t.NumIn() != 1 || t.NumOut() != 1 || t.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+		return nil, fmt.Errorf("listener must be func(event T) error, got <%s>", t)
+	}
+	return b.subscribe(t.In(0), func(event any) error {
+		results := v.Call([]reflect.Value{reflect.ValueOf(event)})
+		if results[0].IsNil() {
+			return nil
+		}
+		return results[0].Interface().(error)
+	}, opts...), nil
+}
+
+func (b *Bus) subscribe(eventType reflect.Type, call func(event any) error, opts ...func(*SubscribeOpts)) *Subscription {
+	o := &SubscribeOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.seq++
+	sub := &Subscription{
+		bus:       b,
+		seq:       b.seq,
+		eventType: eventType,
+		priority:  o.Priority,
+		async:     o.Async,
+		call:      call,
+	}
+	// 插入到相同优先级的最后
+	i := sort.Search(len(b.subs), func(i int) bool {
+		return b.subs[i].priority < sub.priority
+	})
+	b.subs = append(b.subs, nil)
+	copy(b.subs[i+1:], b.subs[i:])
+	b.subs[i] = sub
+	return sub
+}
+
+func (b *Bus) remove(sub *Subscription) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for i, s := range b.subs {
+		if s == sub {
+			// 复制一份, 不影响正在分发的事件
+			subs := make([]*Subscription, 0, len(b.subs)-1)
+			subs = append(subs, b.subs[:i]...)
+			b.subs = append(subs, b.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *Bus) listeners(t reflect.Type) (matched []*Subscription) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for _, s := range b.subs {
+		if s.match(t) {
+			matched = append(matched, s)
+		}
+	}
+	return
+}
+
+// Fire 按优先级分发事件, 同步监听器的错误按 ErrorPolicy 处理, 异步监听器投递到 worker 后立即返回
+func (b *Bus) Fire(event any) (err error) {
+	if event == nil {
+		return
+	}
+	var errs []error
+	for _, sub := range b.listeners(reflect.TypeOf(event)) {
+		if sub.async {
+			b.dispatch(sub, event)
+			continue
+		}
+		e := sub.invoke(event)
+		if e == nil {
+			continue
+		}
+		switch b.opts.Policy {
+		case StopOnError:
+			return e
+		case ContinueOnError:
+			errs = append(errs, e)
+		default:
+			b.opts.ErrorHandler(event, e)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Bus) dispatch(sub *Subscription, event any) {
+	b.state.RLock()
+	if b.closed {
+		b.state.RUnlock()
+		// 关闭后没有 worker, 在当前协程中调用
+		if err := sub.invoke(event); err != nil {
+			b.opts.ErrorHandler(event, err)
+		}
+		return
+	}
+	b.start.Do(func() {
+		b.tasks = make(chan func(), b.opts.QueueSize)
+		for i := 0; i < b.opts.Workers; i++ {
+			go func() {
+				for task := range b.tasks {
+					task()
+				}
+			}()
+		}
+	})
+	// 关闭前计数, Close 等待计数归零后才关闭 tasks, 队列满时不持有锁阻塞
+	b.pending.Add(1)
+	b.state.RUnlock()
+	b.tasks <- func() {
+		defer b.pending.Done()
+		if err := sub.invoke(event); err != nil {
+			b.opts.ErrorHandler(event, err)
+		}
+	}
+}
+
+// Wait 等待已投递的异步事件处理完成
+func (b *Bus) Wait() {
+	b.pending.Wait()
+}
+
+// Close 等待已投递的异步事件处理完成后停止 worker, 之后的异步监听器在 Fire 中同步调用, 可以多次调用
+func (b *Bus) Close() {
+	b.state.Lock()
+	if b.closed {
+		b.state.Unlock()
+		return
+	}
+	b.closed = true
+	b.state.Unlock()
+
+	b.Wait()
+	if b.tasks != nil {
+		close(b.tasks)
+	}
+}
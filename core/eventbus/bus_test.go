@@ -0,0 +1,158 @@
+package eventbus
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type AuditEvent interface {
+	Actor() string
+}
+
+type userCreated struct{ name string }
+
+func (e userCreated) Actor() string { return e.name }
+
+type orderPaid struct{ id int }
+
+func TestBus(t *testing.T) {
+	t.Run("priority and unsubscribe", func(t *testing.T) {
+		b := NewBus()
+		var calls []string
+		SubscribeTo(b, func(e userCreated) error {
+			calls = append(calls, "low")
+			return nil
+		}, WithPriority(-1))
+		SubscribeTo(b, func(e userCreated) error {
+			calls = append(calls, "normal1")
+			return nil
+		})
+		sub := SubscribeTo(b, func(e userCreated) error {
+			calls = append(calls, "normal2")
+			return nil
+		})
+		SubscribeTo(b, func(e userCreated) error {
+			calls = append(calls, "high")
+			return nil
+		}, WithPriority(10))
+
+		require.NoError(t, b.Fire(userCreated{name: "tom"}))
+		require.Equal(t, []string{"high", "normal1", "normal2", "low"}, calls)
+
+		calls = nil
+		sub.Unsubscribe()
+		sub.Unsubscribe()
+		require.NoError(t, b.Fire(userCreated{name: "tom"}))
+		require.Equal(t, []string{"high", "normal1", "low"}, calls)
+	})
+
+	t.Run("interface and wildcard listeners", func(t *testing.T) {
+		b := NewBus()
+		var actors []string
+		var all int
+		SubscribeTo(b, func(e AuditEvent) error {
+			actors = append(actors, e.Actor())
+			return nil
+		})
+		SubscribeTo(b, func(e any) error {
+			all++
+			return nil
+		})
+		_, err := b.Subscribe(func(e orderPaid) error { return nil })
+		require.NoError(t, err)
+		_, err = b.Subscribe(func(e orderPaid) {})
+		require.Error(t, err)
+
+		require.NoError(t, b.Fire(userCreated{name: "tom"}))
+		require.NoError(t, b.Fire(orderPaid{id: 1}))
+		require.Equal(t, []string{"tom"}, actors)
+		require.Equal(t, 2, all)
+	})
+
+	t.Run("error policy", func(t *testing.T) {
+		subscribe := func(b *Bus) *int {
+			var called int
+			SubscribeTo(b, func(e orderPaid) error {
+				called++
+				return errors.New("first")
+			})
+			SubscribeTo(b, func(e orderPaid) error {
+				called++
+				panic("second")
+			})
+			return &called
+		}
+
+		b := NewBus()
+		called := subscribe(b)
+		require.EqualError(t, b.Fire(orderPaid{}), "first")
+		require.Equal(t, 1, *called)
+
+		b = NewBus(WithErrorPolicy(ContinueOnError))
+		called = subscribe(b)
+		err := b.Fire(orderPaid{})
+		require.ErrorContains(t, err, "first")
+		require.ErrorContains(t, err, "listener of <eventbus.orderPaid> panic: second")
+		require.Equal(t, 2, *called)
+
+		var handled []error
+		b = NewBus(WithErrorPolicy(IgnoreError), WithErrorHandler(func(event any, err error) {
+			handled = append(handled, err)
+		}))
+		called = subscribe(b)
+		require.NoError(t, b.Fire(orderPaid{}))
+		require.Equal(t, 2, *called)
+		require.Len(t, handled, 2)
+	})
+
+	t.Run("async", func(t *testing.T) {
+		var lock sync.Mutex
+		var handled []error
+		b := NewBus(WithWorkers(2, 1), WithErrorHandler(func(event any, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			handled = append(handled, err)
+		}))
+		var count atomic.Int32
+		SubscribeTo(b, func(e orderPaid) error {
+			count.Add(1)
+			if e.id%2 == 0 {
+				return errors.New("even")
+			}
+			return nil
+		}, Async())
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, b.Fire(orderPaid{id: i}))
+		}
+		b.Wait()
+		require.EqualValues(t, 10, count.Load())
+		require.Len(t, handled, 5)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		b := NewBus(WithWorkers(1, 1))
+		var count atomic.Int32
+		SubscribeTo(b, func(e orderPaid) error {
+			count.Add(1)
+			if e.id == 0 {
+				// worker 中继续投递的事件在关闭前处理完
+				return b.Fire(orderPaid{id: 1})
+			}
+			return nil
+		}, Async())
+
+		require.NoError(t, b.Fire(orderPaid{id: 0}))
+		b.Close()
+		require.EqualValues(t, 2, count.Load())
+
+		// 关闭后同步调用
+		require.NoError(t, b.Fire(orderPaid{id: 2}))
+		require.EqualValues(t, 3, count.Load())
+		b.Close()
+	})
+}
@@ -2,32 +2,44 @@ package eventbus
 
 import (
 	"reflect"
+	"sync/atomic"
 )
 
 type ListenerFunc[T any] func(event T) error
 
-var listenerMap = make(map[reflect.Type][]reflect.Value)
+var defaultBus atomic.Pointer[Bus]
+
+func init() {
+	defaultBus.Store(NewBus())
+}
+
+// Default 包级函数使用的事件总线
+func Default() *Bus {
+	return defaultBus.Load()
+}
+
+func SetDefault(b *Bus) {
+	defaultBus.Store(b)
+}
+
+// SubscribeTo 在指定事件总线上注册监听器, T 为接口时接收所有实现了该接口的事件, 为 any 时接收所有事件
+func SubscribeTo[T any](b *Bus, listener ListenerFunc[T], opts ...func(*SubscribeOpts)) *Subscription {
+	return b.subscribe(reflect.TypeOf((*T)(nil)).Elem(), func(event any) error {
+		return listener(event.(T))
+	}, opts...)
+}
+
+// Subscribe 在默认事件总线上注册监听器
+func Subscribe[T any](listener ListenerFunc[T], opts ...func(*SubscribeOpts)) *Subscription {
+	return SubscribeTo(Default(), listener, opts...)
+}
 
 func Register[T any](listeners ...ListenerFunc[T]) {
 	for _, listener := range listeners {
-		v := reflect.ValueOf(listener)
-		eventType := v.Type().In(0)
-		listenerMap[eventType] = append(listenerMap[eventType], v)
+		Subscribe(listener)
 	}
 }
 
 func Fire[T any](event T) (err error) {
-	t := reflect.TypeOf(event)
-	listeners, ok := listenerMap[t]
-	if !ok {
-		return
-	}
-	for _, listener := range listeners {
-		results := listener.Call([]reflect.Value{reflect.ValueOf(event)})
-		if !results[0].IsNil() {
-			err = results[0].Interface().(error)
-			return
-		}
-	}
-	return
+	return Default().Fire(event)
 }
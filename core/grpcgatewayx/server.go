@@ -2,23 +2,46 @@ package grpcgatewayx
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
 type Server struct {
 	*http.Server
 	defers []func()
+	ready  atomic.Bool
 }
 
 func (s *Server) Start() error {
 	for _, deferFunc := range s.defers {
 		defer deferFunc()
 	}
-	return s.Server.ListenAndServe()
+	addr := s.Server.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.ready.Store(true)
+	defer s.ready.Store(false)
+	return s.Server.Serve(lis)
+}
+
+// Ready http端口监听成功后就绪
+func (s *Server) Ready() error {
+	if !s.ready.Load() {
+		return errors.New("grpc gateway server is not serving")
+	}
+	return nil
 }
 
 func (s *Server) Stop() error {
+	s.ready.Store(false)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	return s.Server.Shutdown(ctx)
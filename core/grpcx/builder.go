@@ -64,6 +64,10 @@ func (c *GrpcServerBuilder) Build() (server *Server, err error) {
 		return
 	}
 
+	// 作用域子容器需要在业务拦截器之前创建, 业务拦截器才可以向其中绑定如当前用户等请求级别的对象
+	c.UnaryInterceptors = append([]grpc.UnaryServerInterceptor{interceptor.UnaryScopeServerInterceptor}, c.UnaryInterceptors...)
+	c.StreamInterceptors = append([]grpc.StreamServerInterceptor{interceptor.StreamScopeServerInterceptor}, c.StreamInterceptors...)
+
 	// 初始化链路追踪
 	if c.TraceConfig != nil && c.TraceConfig.Endpoint != "" {
 		c.UnaryInterceptors = append(c.UnaryInterceptors, trace.UnaryTraceServerInterceptor)
@@ -99,11 +103,10 @@ func (c *GrpcServerBuilder) Build() (server *Server, err error) {
 		server.defers = append(server.defers, providerDefer)
 	}
 
-	var healthCheckServer *health.Server
 	// 注册健康检查服务
 	if c.HealthCheck {
-		healthCheckServer = health.NewServer()
-		healthgrpc.RegisterHealthServer(server.Server, healthCheckServer)
+		server.healthCheckServer = health.NewServer()
+		healthgrpc.RegisterHealthServer(server.Server, server.healthCheckServer)
 	}
 
 	// 注册反射服务
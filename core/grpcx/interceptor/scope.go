@@ -0,0 +1,31 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/goslacker/slacker/core/container"
+	"google.golang.org/grpc"
+)
+
+// UnaryScopeServerInterceptor 为每个请求创建作用域子容器, 请求结束后释放, 应放在其他拦截器之前
+func UnaryScopeServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx, scope := container.Scope(ctx)
+	defer scope.Close()
+	return handler(ctx, req)
+}
+
+type scopeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *scopeStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamScopeServerInterceptor 为每个流创建作用域子容器, 流结束后释放, 应放在其他拦截器之前
+func StreamScopeServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, scope := container.Scope(ss.Context())
+	defer scope.Close()
+	return handler(srv, &scopeStream{ServerStream: ss, ctx: ctx})
+}
@@ -2,6 +2,7 @@ package grpcx
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -9,6 +10,7 @@ import (
 	"net/http/pprof"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/goslacker/slacker/core/registry"
@@ -24,9 +26,11 @@ type Server struct {
 	pprofPort         int                // pprof端口,如果为1-65535,则开启pprof
 	pprofHttpServer   *http.Server       // pprof http 服务器
 	addr              string             //grpc服务端口
+	ready             atomic.Bool        //是否已开始监听
 }
 
-func (s *Server) Start(ctx context.Context) {
+// Start 启动服务并阻塞, 端口监听或服务注册失败时返回错误
+func (s *Server) Start(ctx context.Context) (err error) {
 	if s.pprofPort > 0 {
 		go func() {
 			mux := http.NewServeMux()
@@ -51,21 +55,6 @@ func (s *Server) Start(ctx context.Context) {
 		}()
 	}
 
-	lis, err := net.Listen("tcp", s.addr)
-	if err != nil {
-		panic(fmt.Errorf("tcp listen port failed: %w", err))
-	}
-
-	for serviceName := range s.GetServiceInfo() {
-		if strings.Contains(serviceName, "grpc") {
-			continue
-		}
-		err = s.registrar.Register(ctx, serviceName)
-		if err != nil {
-			panic(fmt.Errorf("register service %s failed: %w", serviceName, err))
-		}
-	}
-
 	defer func() {
 		if s.pprofHttpServer != nil {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -77,15 +66,49 @@ func (s *Server) Start(ctx context.Context) {
 		}
 	}()
 
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("tcp listen port failed: %w", err)
+	}
+
+	if s.registrar != nil {
+		for serviceName := range s.GetServiceInfo() {
+			if strings.Contains(serviceName, "grpc") {
+				continue
+			}
+			err = s.registrar.Register(ctx, serviceName)
+			if err != nil {
+				_ = lis.Close()
+				return fmt.Errorf("register service %s failed: %w", serviceName, err)
+			}
+		}
+	}
+
 	slog.Info("Serving gRPC on " + s.addr)
+	s.ready.Store(true)
+	defer s.ready.Store(false)
 	err = s.Server.Serve(lis)
 	if err != nil {
-		slog.Error("grpc server shutdown", "error", err)
-	} else {
-		slog.Info("grpc server shutdown")
+		return fmt.Errorf("grpc server shutdown: %w", err)
 	}
+	slog.Info("grpc server shutdown")
+	return
 }
 
 func (s *Server) Stop(ctx context.Context) {
+	s.ready.Store(false)
 	s.Server.Stop()
 }
+
+// Ready grpc端口监听成功后就绪
+func (s *Server) Ready() error {
+	if !s.ready.Load() {
+		return errors.New("grpc server is not serving")
+	}
+	return nil
+}
+
+// HealthCheckServer 开启健康检查时返回健康检查服务, 否则返回nil
+func (s *Server) HealthCheckServer() *health.Server {
+	return s.healthCheckServer
+}
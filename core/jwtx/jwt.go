@@ -85,7 +85,11 @@ func FieldFromContext[T any](ctx context.Context, field string) (result T, err e
 		err = fmt.Errorf("claims not found")
 		return
 	}
-	claims := tmp.(jwt.MapClaims)
+	claims, ok := tmp.(jwt.MapClaims)
+	if !ok {
+		err = fmt.Errorf("claims type %T is not supported", tmp)
+		return
+	}
 	v := claims[field]
 	if v == nil {
 		err = fmt.Errorf("field %s not found", field)
@@ -0,0 +1,69 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// NewDBLocker 基于数据库 advisory lock 的锁, 支持 mysql 和 postgres, 锁随连接释放
+func NewDBLocker(db *sql.DB, dialect string) (*DBLocker, error) {
+	switch strings.ToLower(dialect) {
+	case "mysql":
+		return &DBLocker{db: db, lock: "SELECT GET_LOCK(?, -1)", unlock: "SELECT RELEASE_LOCK(?)", arg: mysqlKey}, nil
+	case "postgres", "postgresql":
+		return &DBLocker{db: db, lock: "SELECT 1 FROM pg_advisory_lock($1)", unlock: "SELECT pg_advisory_unlock($1)", arg: postgresKey}, nil
+	default:
+		return nil, fmt.Errorf("database <%s> does not support advisory lock", dialect)
+	}
+}
+
+type DBLocker struct {
+	db     *sql.DB
+	lock   string
+	unlock string
+	arg    func(key string) any
+}
+
+func (d *DBLocker) Lock(ctx context.Context, key string) (unlock func() error, err error) {
+	// 锁属于会话, 加锁和释放必须在同一个连接上
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	arg := d.arg(key)
+	var ok sql.NullInt64
+	if err = conn.QueryRowContext(ctx, d.lock, arg).Scan(&ok); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("lock <%s> failed: %w", key, err)
+	}
+	if !ok.Valid || ok.Int64 != 1 {
+		_ = conn.Close()
+		return nil, fmt.Errorf("lock <%s> failed", key)
+	}
+	return func() error {
+		_, err := conn.ExecContext(context.Background(), d.unlock, arg)
+		return errors.Join(err, conn.Close())
+	}, nil
+}
+
+// mysqlKey mysql 的锁名最长 64 个字符
+func mysqlKey(key string) any {
+	if len(key) <= 64 {
+		return key
+	}
+	return fmt.Sprintf("%.47s#%016x", key, hashKey(key))
+}
+
+func postgresKey(key string) any {
+	return int64(hashKey(key))
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
@@ -0,0 +1,41 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const etcdPrefix = "/slacker/lock/"
+
+// NewEtcdLocker 基于 etcd 租约的锁, 持有锁的进程退出后租约过期自动释放
+func NewEtcdLocker(c *clientv3.Client) *EtcdLocker {
+	return &EtcdLocker{c: c}
+}
+
+type EtcdLocker struct {
+	c *clientv3.Client
+}
+
+// Close 关闭 etcd 客户端, 由容器在 App 停止时调用
+func (e *EtcdLocker) Close() error {
+	return e.c.Close()
+}
+
+func (e *EtcdLocker) Lock(ctx context.Context, key string) (unlock func() error, err error) {
+	session, err := concurrency.NewSession(e.c, concurrency.WithTTL(20))
+	if err != nil {
+		return nil, fmt.Errorf("create etcd session failed: %w", err)
+	}
+	mutex := concurrency.NewMutex(session, etcdPrefix+key)
+	if err = mutex.Lock(ctx); err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("lock <%s> failed: %w", key, err)
+	}
+	return func() error {
+		defer session.Close()
+		return mutex.Unlock(context.Background())
+	}, nil
+}
@@ -0,0 +1,41 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Locker 分布式锁, Lock 阻塞直到获得锁或 ctx 结束, 返回的 unlock 释放锁
+type Locker interface {
+	Lock(ctx context.Context, key string) (unlock func() error, err error)
+}
+
+// BuildLocker 按类型创建锁, db 使用数据库的 advisory lock, dialect 为数据库 DSN 的 scheme
+func BuildLocker(typ string, db *sql.DB, dialect string, endpoints []string) (locker Locker, err error) {
+	switch typ {
+	case "memory":
+		locker = NewMemoryLocker()
+	case "db":
+		locker, err = NewDBLocker(db, dialect)
+	case "etcd":
+		var c *clientv3.Client
+		c, err = clientv3.New(clientv3.Config{
+			Endpoints:            endpoints,
+			DialKeepAliveTime:    30 * time.Second,
+			DialKeepAliveTimeout: 60 * time.Second,
+			DialTimeout:          10 * time.Second,
+		})
+		if err != nil {
+			err = fmt.Errorf("new etcd client failed: %w", err)
+			return
+		}
+		locker = NewEtcdLocker(c)
+	default:
+		err = fmt.Errorf("unknown lock type: %s", typ)
+	}
+	return
+}
@@ -0,0 +1,81 @@
+package lock
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker()
+
+	t.Run("exclusive", func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			running int
+			peak    int
+			mu      sync.Mutex
+		)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock, err := locker.Lock(context.Background(), "migrate")
+				require.NoError(t, err)
+				mu.Lock()
+				running++
+				if running > peak {
+					peak = running
+				}
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				require.NoError(t, unlock())
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, 1, peak)
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		unlock, err := locker.Lock(context.Background(), "seed")
+		require.NoError(t, err)
+
+		// 其他 key 不受影响
+		other, err := locker.Lock(context.Background(), "migrate")
+		require.NoError(t, err)
+		require.NoError(t, other())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = locker.Lock(ctx, "seed")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.NoError(t, unlock())
+		require.NoError(t, unlock())
+		unlock, err = locker.Lock(context.Background(), "seed")
+		require.NoError(t, err)
+		require.NoError(t, unlock())
+	})
+}
+
+func TestBuildLocker(t *testing.T) {
+	_, err := BuildLocker("db", nil, "sqlite", nil)
+	require.EqualError(t, err, "database <sqlite> does not support advisory lock")
+	_, err = BuildLocker("zookeeper", nil, "", nil)
+	require.EqualError(t, err, "unknown lock type: zookeeper")
+
+	locker, err := BuildLocker("db", nil, "mysql", nil)
+	require.NoError(t, err)
+	require.IsType(t, &DBLocker{}, locker)
+
+	key := mysqlKey(strings.Repeat("k", 100)).(string)
+	require.Len(t, key, 64)
+	require.Equal(t, "short", mysqlKey("short"))
+}
@@ -0,0 +1,45 @@
+package lock
+
+import (
+	"context"
+	"sync"
+)
+
+// NewMemoryLocker 进程内的锁, 用于测试和单实例部署
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]chan struct{})}
+}
+
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+func (m *MemoryLocker) Lock(ctx context.Context, key string) (unlock func() error, err error) {
+	for {
+		m.mu.Lock()
+		held, ok := m.locks[key]
+		if !ok {
+			released := make(chan struct{})
+			m.locks[key] = released
+			m.mu.Unlock()
+			var once sync.Once
+			return func() error {
+				once.Do(func() {
+					m.mu.Lock()
+					delete(m.locks, key)
+					m.mu.Unlock()
+					close(released)
+				})
+				return nil
+			}, nil
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-held:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
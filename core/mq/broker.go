@@ -0,0 +1,242 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	slackertrace "github.com/goslacker/slacker/core/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	HeaderError         = "x-mq-error"          // 转入死信前最后一次处理的错误
+	HeaderOriginalTopic = "x-mq-original-topic" // 转入死信前的 topic
+	HeaderOriginalID    = "x-mq-original-id"
+)
+
+var _ Publisher = (*Broker)(nil)
+var _ Subscriber = (*Broker)(nil)
+
+// Broker 在驱动之上实现发布订阅, 负责链路追踪上下文的传递, 确认, 重试和死信
+type Broker struct {
+	driver Driver
+}
+
+func NewBroker(driver Driver) *Broker {
+	return &Broker{driver: driver}
+}
+
+func (b *Broker) Driver() Driver {
+	return b.driver
+}
+
+// Publish 发布消息, 当前链路追踪上下文会写入消息的 Headers
+func (b *Broker) Publish(ctx context.Context, topic string, msgs ...*Message) (err error) {
+	for _, msg := range msgs {
+		err = b.publish(ctx, topic, msg)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (b *Broker) publish(ctx context.Context, topic string, msg *Message) (err error) {
+	ctx, span := otel.Tracer("slacker").Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", topic)),
+	)
+	defer span.End()
+
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Topic = topic
+	otel.GetTextMapPropagator().Inject(ctx, slackertrace.HeaderTextMapCarrier(msg.Headers))
+
+	msg.ID, err = b.driver.Publish(ctx, topic, msg)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("publish message to <%s> failed: %w", topic, err)
+	}
+	span.SetAttributes(attribute.String("messaging.message.id", msg.ID))
+	return
+}
+
+func (b *Broker) Close() error {
+	return b.driver.Close()
+}
+
+type SubscribeOpts struct {
+	Consumer      string // 消费者名, 同一个组中应唯一, 默认为 主机名-进程id
+	Concurrency   int    // 并发处理的消息数, 默认 1
+	BatchSize     int    // 每次拉取的消息数, 默认 10
+	MaxDeliveries int    // 最大投递次数, 超过后转入死信, 默认 5
+	DeadLetter    string // 死信 topic, 默认为 <topic>.dlq, 为 "-" 时直接丢弃
+	RetryDelay    func(attempts int) time.Duration
+}
+
+func WithConsumer(name string) func(*SubscribeOpts) {
+	return func(opts *SubscribeOpts) {
+		opts.Consumer = name
+	}
+}
+
+func WithConcurrency(concurrency int) func(*SubscribeOpts) {
+	return func(opts *SubscribeOpts) {
+		opts.Concurrency = concurrency
+	}
+}
+
+func WithBatchSize(size int) func(*SubscribeOpts) {
+	return func(opts *SubscribeOpts) {
+		opts.BatchSize = size
+	}
+}
+
+func WithMaxDeliveries(maxDeliveries int) func(*SubscribeOpts) {
+	return func(opts *SubscribeOpts) {
+		opts.MaxDeliveries = maxDeliveries
+	}
+}
+
+func WithDeadLetter(topic string) func(*SubscribeOpts) {
+	return func(opts *SubscribeOpts) {
+		opts.DeadLetter = topic
+	}
+}
+
+func WithRetryDelay(delay func(attempts int) time.Duration) func(*SubscribeOpts) {
+	return func(opts *SubscribeOpts) {
+		opts.RetryDelay = delay
+	}
+}
+
+func defaultConsumer() string {
+	host, _ := os.Hostname()
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
+
+// Subscribe 拉取并处理消息直到 ctx 取消, 处理中的消息完成后返回
+func (b *Broker) Subscribe(ctx context.Context, topic string, group string, handler Handler, opts ...func(*SubscribeOpts)) error {
+	o := &SubscribeOpts{
+		Consumer:      defaultConsumer(),
+		Concurrency:   1,
+		BatchSize:     10,
+		MaxDeliveries: 5,
+		DeadLetter:    topic + ".dlq",
+		RetryDelay: func(attempts int) time.Duration {
+			return time.Duration(attempts) * time.Second
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.Concurrency = max(o.Concurrency, 1)
+	o.BatchSize = max(o.BatchSize, o.Concurrency)
+
+	sem := make(chan struct{}, o.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for ctx.Err() == nil {
+		deliveries, err := b.driver.Fetch(ctx, topic, group, o.Consumer, o.BatchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if err == ErrClosed {
+				return err
+			}
+			slog.Error("fetch message failed", "topic", topic, "group", group, "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for _, d := range deliveries {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				// 已拉取的消息在取消后仍然处理完
+				b.handle(context.WithoutCancel(ctx), group, d, handler, o)
+			}()
+		}
+	}
+	return nil
+}
+
+func (b *Broker) handle(ctx context.Context, group string, d Delivery, handler Handler, opts *SubscribeOpts) {
+	msg := d.Message()
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, slackertrace.HeaderTextMapCarrier(msg.Headers))
+	ctx, span := otel.Tracer("slacker").Start(ctx, "process "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String("messaging.consumer.group.name", group),
+			attribute.String("messaging.message.id", msg.ID),
+			attribute.Int("messaging.message.delivery_count", msg.Attempts),
+		),
+	)
+	defer span.End()
+
+	err := callHandler(ctx, handler, msg)
+	if err == nil {
+		if e := d.Ack(ctx); e != nil {
+			slog.Error("ack message failed", "topic", msg.Topic, "id", msg.ID, "error", e)
+		}
+		return
+	}
+	span.SetStatus(codes.Error, err.Error())
+
+	if msg.Attempts < opts.MaxDeliveries {
+		slog.Warn("handle message failed, will retry", "topic", msg.Topic, "id", msg.ID, "attempts", msg.Attempts, "error", err)
+		if e := d.Nack(ctx, opts.RetryDelay(msg.Attempts)); e != nil {
+			slog.Error("nack message failed", "topic", msg.Topic, "id", msg.ID, "error", e)
+		}
+		return
+	}
+
+	slog.Error("handle message failed, move to dead letter", "topic", msg.Topic, "id", msg.ID, "attempts", msg.Attempts, "dead_letter", opts.DeadLetter, "error", err)
+	if opts.DeadLetter != "-" {
+		dead := NewMessage(msg.Payload)
+		for k, v := range msg.Headers {
+			dead.Headers[k] = v
+		}
+		dead.Headers[HeaderError] = err.Error()
+		dead.Headers[HeaderOriginalTopic] = msg.Topic
+		dead.Headers[HeaderOriginalID] = msg.ID
+		if e := b.publish(ctx, opts.DeadLetter, dead); e != nil {
+			slog.Error("publish dead letter failed", "topic", msg.Topic, "id", msg.ID, "error", e)
+			_ = d.Nack(ctx, opts.RetryDelay(msg.Attempts))
+			return
+		}
+	}
+	if e := d.Ack(ctx); e != nil {
+		slog.Error("ack message failed", "topic", msg.Topic, "id", msg.ID, "error", e)
+	}
+}
+
+func callHandler(ctx context.Context, handler Handler, msg *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handle message panic: %v", r)
+		}
+	}()
+	return handler(ctx, msg)
+}
@@ -0,0 +1,167 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	traceSdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type received struct {
+	lock sync.Mutex
+	msgs []*Message
+	ctxs []context.Context
+}
+
+func (r *received) add(ctx context.Context, msg *Message) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.msgs = append(r.msgs, msg)
+	r.ctxs = append(r.ctxs, ctx)
+}
+
+func (r *received) len() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.msgs)
+}
+
+func subscribe(t *testing.T, broker *Broker, topic string, group string, handler Handler, opts ...func(*SubscribeOpts)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, broker.Subscribe(ctx, topic, group, handler, opts...))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestBroker(t *testing.T) {
+	newBroker := func() *Broker {
+		return NewBroker(NewMemoryDriver(func(opts *MemoryOpts) {
+			opts.Block = 50 * time.Millisecond
+		}))
+	}
+
+	t.Run("consumer groups", func(t *testing.T) {
+		broker := newBroker()
+		var billing, shipping1, shipping2 received
+		for i := 0; i < 10; i++ {
+			require.NoError(t, broker.Publish(context.Background(), "orders", NewMessage([]byte("order"))))
+		}
+		subscribe(t, broker, "orders", "billing", func(ctx context.Context, msg *Message) error {
+			billing.add(ctx, msg)
+			return nil
+		})
+		shipping := func(r *received) Handler {
+			return func(ctx context.Context, msg *Message) error {
+				r.add(ctx, msg)
+				time.Sleep(time.Millisecond)
+				return nil
+			}
+		}
+		subscribe(t, broker, "orders", "shipping", shipping(&shipping1), WithConsumer("s1"), WithBatchSize(1))
+		subscribe(t, broker, "orders", "shipping", shipping(&shipping2), WithConsumer("s2"), WithBatchSize(1))
+
+		require.Eventually(t, func() bool {
+			return billing.len() == 10 && shipping1.len()+shipping2.len() == 10
+		}, 2*time.Second, 10*time.Millisecond)
+		require.Equal(t, "orders", billing.msgs[0].Topic)
+		require.Equal(t, 1, billing.msgs[0].Attempts)
+	})
+
+	t.Run("retry and dead letter", func(t *testing.T) {
+		broker := newBroker()
+		var flaky, failing, dead received
+		retry := WithRetryDelay(func(attempts int) time.Duration { return 10 * time.Millisecond })
+		subscribe(t, broker, "flaky", "g", func(ctx context.Context, msg *Message) error {
+			flaky.add(ctx, msg)
+			if msg.Attempts < 2 {
+				return errors.New("try again")
+			}
+			return nil
+		}, retry)
+		subscribe(t, broker, "failing", "g", func(ctx context.Context, msg *Message) error {
+			failing.add(ctx, msg)
+			panic("boom")
+		}, retry, WithMaxDeliveries(3))
+		subscribe(t, broker, "failing.dlq", "g", func(ctx context.Context, msg *Message) error {
+			dead.add(ctx, msg)
+			return nil
+		})
+
+		require.NoError(t, broker.Publish(context.Background(), "flaky", NewMessage([]byte("a"))))
+		msg := NewMessage([]byte("b"))
+		msg.Headers["tenant"] = "t1"
+		require.NoError(t, broker.Publish(context.Background(), "failing", msg))
+
+		require.Eventually(t, func() bool {
+			return flaky.len() == 2 && dead.len() == 1
+		}, 2*time.Second, 10*time.Millisecond)
+		require.Equal(t, 3, failing.len())
+		d := dead.msgs[0]
+		require.Equal(t, []byte("b"), d.Payload)
+		require.Equal(t, "t1", d.Headers["tenant"])
+		require.Equal(t, "failing", d.Headers[HeaderOriginalTopic])
+		require.Equal(t, msg.ID, d.Headers[HeaderOriginalID])
+		require.Equal(t, "handle message panic: boom", d.Headers[HeaderError])
+	})
+
+	t.Run("redeliver after ack timeout", func(t *testing.T) {
+		driver := NewMemoryDriver(func(opts *MemoryOpts) {
+			opts.AckTimeout = 20 * time.Millisecond
+			opts.Block = 100 * time.Millisecond
+		})
+		_, err := driver.Publish(context.Background(), "jobs", NewMessage([]byte("x")))
+		require.NoError(t, err)
+		deliveries, err := driver.Fetch(context.Background(), "jobs", "g", "c1", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+
+		deliveries, err = driver.Fetch(context.Background(), "jobs", "g", "c2", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, 2, deliveries[0].Message().Attempts)
+		require.NoError(t, deliveries[0].Ack(context.Background()))
+
+		deliveries, err = driver.Fetch(context.Background(), "jobs", "g", "c1", 10)
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	})
+
+	t.Run("trace propagation", func(t *testing.T) {
+		tp := traceSdk.NewTracerProvider()
+		otel.SetTracerProvider(tp)
+		defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+		broker := newBroker()
+		var got received
+		subscribe(t, broker, "traced", "g", func(ctx context.Context, msg *Message) error {
+			got.add(ctx, msg)
+			return nil
+		})
+		ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+		require.NoError(t, broker.Publish(ctx, "traced", NewMessage(nil)))
+		span.End()
+
+		require.Eventually(t, func() bool { return got.len() == 1 }, 2*time.Second, 10*time.Millisecond)
+		require.NotEmpty(t, got.msgs[0].Headers["traceparent"])
+		require.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(got.ctxs[0]).TraceID())
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		broker := newBroker()
+		require.NoError(t, broker.Close())
+		require.ErrorIs(t, broker.Publish(context.Background(), "x", NewMessage(nil)), ErrClosed)
+		require.ErrorIs(t, broker.Subscribe(context.Background(), "x", "g", nil), ErrClosed)
+	})
+}
@@ -0,0 +1,201 @@
+package mq
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var _ Driver = (*MemoryDriver)(nil)
+
+type MemoryOpts struct {
+	AckTimeout time.Duration // 拉取后超过该时间未确认的消息会重新投递, 默认 30s
+	Block      time.Duration // 没有消息时 Fetch 阻塞的最长时间, 默认 1s
+}
+
+// MemoryDriver 进程内的消息队列驱动, 用于测试和单机场景, 消息不持久化
+type MemoryDriver struct {
+	opts   MemoryOpts
+	lock   sync.Mutex
+	seq    uint64
+	topics map[string]*memoryTopic
+	notify chan struct{} // 有新消息或消息重新可投递时关闭并替换
+	closed bool
+}
+
+type memoryTopic struct {
+	messages []*Message
+	groups   map[string]*memoryGroup
+}
+
+type memoryGroup struct {
+	offset  int                       // 下一条未投递的消息
+	pending map[string]*memoryPending // 已投递未确认的消息
+}
+
+type memoryPending struct {
+	msg         *Message
+	deliveries  int
+	availableAt time.Time // 可以重新投递的时间
+}
+
+func NewMemoryDriver(opts ...func(*MemoryOpts)) *MemoryDriver {
+	d := &MemoryDriver{
+		opts: MemoryOpts{
+			AckTimeout: 30 * time.Second,
+			Block:      time.Second,
+		},
+		topics: make(map[string]*memoryTopic),
+		notify: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	return d
+}
+
+func (d *MemoryDriver) topic(name string) *memoryTopic {
+	t, ok := d.topics[name]
+	if !ok {
+		t = &memoryTopic{groups: make(map[string]*memoryGroup)}
+		d.topics[name] = t
+	}
+	return t
+}
+
+func (d *MemoryDriver) wakeup() {
+	close(d.notify)
+	d.notify = make(chan struct{})
+}
+
+func (d *MemoryDriver) Publish(ctx context.Context, topic string, msg *Message) (id string, err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.closed {
+		return "", ErrClosed
+	}
+	d.seq++
+	id = strconv.FormatUint(d.seq, 10)
+	t := d.topic(topic)
+	t.messages = append(t.messages, &Message{
+		ID:      id,
+		Topic:   topic,
+		Payload: msg.Payload,
+		Headers: maps.Clone(msg.Headers),
+	})
+	d.wakeup()
+	return
+}
+
+func (d *MemoryDriver) Fetch(ctx context.Context, topic string, group string, consumer string, count int) (deliveries []Delivery, err error) {
+	timer := time.NewTimer(d.opts.Block)
+	defer timer.Stop()
+	for {
+		d.lock.Lock()
+		if d.closed {
+			d.lock.Unlock()
+			return nil, ErrClosed
+		}
+		var next time.Time
+		deliveries, next = d.take(topic, group, count)
+		notify := d.notify
+		d.lock.Unlock()
+		if len(deliveries) > 0 {
+			return
+		}
+
+		var retry <-chan time.Time
+		if !next.IsZero() {
+			retry = time.After(time.Until(next))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return
+		case <-notify:
+		case <-retry:
+		}
+	}
+}
+
+// take 先取可以重新投递的消息, 再取新消息, 没有消息时返回最近一条待重新投递消息的时间
+func (d *MemoryDriver) take(topic string, group string, count int) (deliveries []Delivery, next time.Time) {
+	t := d.topic(topic)
+	g, ok := t.groups[group]
+	if !ok {
+		g = &memoryGroup{pending: make(map[string]*memoryPending)}
+		t.groups[group] = g
+	}
+
+	now := time.Now()
+	deliver := func(p *memoryPending) {
+		p.deliveries++
+		p.availableAt = now.Add(d.opts.AckTimeout)
+		msg := *p.msg
+		msg.Headers = maps.Clone(p.msg.Headers)
+		msg.Attempts = p.deliveries
+		deliveries = append(deliveries, &memoryDelivery{driver: d, group: g, msg: &msg, deliveries: p.deliveries})
+	}
+	for _, p := range g.pending {
+		if len(deliveries) >= count {
+			return
+		}
+		if p.availableAt.After(now) {
+			if next.IsZero() || p.availableAt.Before(next) {
+				next = p.availableAt
+			}
+			continue
+		}
+		deliver(p)
+	}
+	for len(deliveries) < count && g.offset < len(t.messages) {
+		p := &memoryPending{msg: t.messages[g.offset]}
+		g.pending[p.msg.ID] = p
+		g.offset++
+		deliver(p)
+	}
+	return
+}
+
+func (d *MemoryDriver) Close() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.closed {
+		d.closed = true
+		d.wakeup()
+	}
+	return nil
+}
+
+type memoryDelivery struct {
+	driver     *MemoryDriver
+	group      *memoryGroup
+	msg        *Message
+	deliveries int // 用于忽略超时后重新投递的旧确认
+}
+
+func (m *memoryDelivery) Message() *Message {
+	return m.msg
+}
+
+func (m *memoryDelivery) Ack(ctx context.Context) error {
+	m.driver.lock.Lock()
+	defer m.driver.lock.Unlock()
+	if p, ok := m.group.pending[m.msg.ID]; ok && p.deliveries == m.deliveries {
+		delete(m.group.pending, m.msg.ID)
+	}
+	return nil
+}
+
+func (m *memoryDelivery) Nack(ctx context.Context, delay time.Duration) error {
+	m.driver.lock.Lock()
+	defer m.driver.lock.Unlock()
+	if p, ok := m.group.pending[m.msg.ID]; ok && p.deliveries == m.deliveries {
+		p.availableAt = time.Now().Add(delay)
+		m.driver.wakeup()
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrClosed = errors.New("broker is closed")
+
+// Message 消息, Headers 用于传递链路追踪等上下文
+type Message struct {
+	ID       string // 由驱动生成
+	Topic    string
+	Payload  []byte
+	Headers  map[string]string
+	Attempts int // 第几次投递, 从1开始
+}
+
+func NewMessage(payload []byte) *Message {
+	return &Message{
+		Payload: payload,
+		Headers: make(map[string]string),
+	}
+}
+
+// Handler 处理消息, 返回nil时确认消息, 返回错误时稍后重新投递, 超过最大投递次数后转入死信
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher 发布消息
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msgs ...*Message) error
+}
+
+// Subscriber 以消费组的方式订阅, 同一个组中的消费者分摊消息, 不同组各自收到全部消息, 阻塞直到 ctx 取消
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string, group string, handler Handler, opts ...func(*SubscribeOpts)) error
+}
+
+// Delivery 驱动拉取到的一条待确认消息
+type Delivery interface {
+	Message() *Message
+	Ack(ctx context.Context) error
+	// Nack 不确认消息, 至少在 delay 之后重新投递, 不支持延迟的驱动按自身的超时时间重新投递
+	Nack(ctx context.Context, delay time.Duration) error
+}
+
+// Driver 消息队列驱动
+type Driver interface {
+	// Publish 写入消息, 返回消息id
+	Publish(ctx context.Context, topic string, msg *Message) (id string, err error)
+	// Fetch 从消费组中拉取最多 count 条消息, 包括超时未确认和需要重新投递的消息, 没有消息时阻塞一段时间后返回空
+	// 消费组不存在时自动创建, 从 topic 中保留的第一条消息开始消费
+	Fetch(ctx context.Context, topic string, group string, consumer string, count int) ([]Delivery, error)
+	Close() error
+}
@@ -0,0 +1,207 @@
+package redismq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goslacker/slacker/core/mq"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ mq.Driver = (*Driver)(nil)
+
+type Opts struct {
+	MaxLen    int64         // 每个 stream 保留的最大消息数(近似裁剪), 为0时不裁剪
+	Block     time.Duration // 没有消息时 Fetch 阻塞的最长时间, 默认 1s
+	ClaimIdle time.Duration // 超过该时间未确认的消息会被重新投递, 默认 30s
+}
+
+func WithMaxLen(maxLen int64) func(*Opts) {
+	return func(opts *Opts) {
+		opts.MaxLen = maxLen
+	}
+}
+
+func WithBlock(block time.Duration) func(*Opts) {
+	return func(opts *Opts) {
+		opts.Block = block
+	}
+}
+
+func WithClaimIdle(idle time.Duration) func(*Opts) {
+	return func(opts *Opts) {
+		opts.ClaimIdle = idle
+	}
+}
+
+// Driver 基于 Redis Streams 的消息队列驱动, topic 对应 stream, 消费组对应 stream 的消费组
+type Driver struct {
+	client redis.UniversalClient
+	opts   Opts
+	groups sync.Map // 已创建的消费组
+}
+
+func New(client redis.UniversalClient, opts ...func(*Opts)) *Driver {
+	d := &Driver{
+		client: client,
+		opts: Opts{
+			Block:     time.Second,
+			ClaimIdle: 30 * time.Second,
+		},
+	}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	return d
+}
+
+func (d *Driver) Publish(ctx context.Context, topic string, msg *mq.Message) (id string, err error) {
+	values := map[string]any{"payload": msg.Payload}
+	if len(msg.Headers) > 0 {
+		var headers []byte
+		headers, err = json.Marshal(msg.Headers)
+		if err != nil {
+			return
+		}
+		values["headers"] = headers
+	}
+	return d.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: topic,
+		MaxLen: d.opts.MaxLen,
+		Approx: d.opts.MaxLen > 0,
+		Values: values,
+	}).Result()
+}
+
+func (d *Driver) ensureGroup(ctx context.Context, topic string, group string) error {
+	key := topic + "\x00" + group
+	if _, ok := d.groups.Load(key); ok {
+		return nil
+	}
+	err := d.client.XGroupCreateMkStream(ctx, topic, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create consumer group <%s> of <%s> failed: %w", group, topic, err)
+	}
+	d.groups.Store(key, struct{}{})
+	return nil
+}
+
+func (d *Driver) Fetch(ctx context.Context, topic string, group string, consumer string, count int) (deliveries []mq.Delivery, err error) {
+	err = d.ensureGroup(ctx, topic, group)
+	if err != nil {
+		return
+	}
+
+	// 先认领超时未确认的消息
+	claimed, _, err := d.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   topic,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  d.opts.ClaimIdle,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if errors.Is(err, redis.ErrClosed) {
+		return nil, mq.ErrClosed
+	}
+	if err != nil {
+		return
+	}
+	if len(claimed) > 0 {
+		var attempts map[string]int
+		attempts, err = d.attempts(ctx, topic, group, claimed)
+		if err != nil {
+			return
+		}
+		for _, m := range claimed {
+			deliveries = append(deliveries, d.delivery(topic, group, consumer, m, attempts[m.ID]))
+		}
+		return
+	}
+
+	streams, err := d.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{topic, ">"},
+		Count:    int64(count),
+		Block:    d.opts.Block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, stream := range streams {
+		for _, m := range stream.Messages {
+			deliveries = append(deliveries, d.delivery(topic, group, consumer, m, 1))
+		}
+	}
+	return
+}
+
+// attempts 查询认领的消息已投递的次数
+func (d *Driver) attempts(ctx context.Context, topic string, group string, msgs []redis.XMessage) (attempts map[string]int, err error) {
+	pending, err := d.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: topic,
+		Group:  group,
+		Start:  msgs[0].ID,
+		End:    msgs[len(msgs)-1].ID,
+		Count:  int64(len(msgs)),
+	}).Result()
+	if err != nil {
+		return
+	}
+	attempts = make(map[string]int, len(pending))
+	for _, p := range pending {
+		attempts[p.ID] = int(p.RetryCount)
+	}
+	return
+}
+
+func (d *Driver) delivery(topic string, group string, consumer string, m redis.XMessage, attempts int) *delivery {
+	msg := &mq.Message{
+		ID:       m.ID,
+		Topic:    topic,
+		Headers:  make(map[string]string),
+		Attempts: max(attempts, 1),
+	}
+	if payload, ok := m.Values["payload"].(string); ok {
+		msg.Payload = []byte(payload)
+	}
+	if headers, ok := m.Values["headers"].(string); ok {
+		_ = json.Unmarshal([]byte(headers), &msg.Headers)
+	}
+	return &delivery{driver: d, group: group, consumer: consumer, msg: msg}
+}
+
+func (d *Driver) Close() error {
+	return d.client.Close()
+}
+
+type delivery struct {
+	driver   *Driver
+	group    string
+	consumer string
+	msg      *mq.Message
+}
+
+func (r *delivery) Message() *mq.Message {
+	return r.msg
+}
+
+func (r *delivery) Ack(ctx context.Context) error {
+	return r.driver.client.XAck(ctx, r.msg.Topic, r.group, r.msg.ID).Err()
+}
+
+// Nack 消息留在待确认列表中, 通过重置空闲时间使其在 delay 之后可以被重新认领, 投递次数保持不变
+func (r *delivery) Nack(ctx context.Context, delay time.Duration) error {
+	idle := max(r.driver.opts.ClaimIdle-delay, 0)
+	return r.driver.client.Do(ctx, "XCLAIM", r.msg.Topic, r.group, r.consumer, 0, r.msg.ID,
+		"IDLE", idle.Milliseconds(), "RETRYCOUNT", r.msg.Attempts, "JUSTID").Err()
+}
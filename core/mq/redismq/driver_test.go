@@ -0,0 +1,106 @@
+package redismq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/goslacker/slacker/core/mq"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newDriver(t *testing.T, opts ...func(*Opts)) *Driver {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	opts = append([]func(*Opts){WithBlock(50 * time.Millisecond)}, opts...)
+	return New(client, opts...)
+}
+
+func TestDriver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("publish and fetch", func(t *testing.T) {
+		d := newDriver(t)
+		msg := mq.NewMessage([]byte("hello"))
+		msg.Headers["traceparent"] = "00-1-2-01"
+		id, err := d.Publish(ctx, "orders", msg)
+		require.NoError(t, err)
+
+		deliveries, err := d.Fetch(ctx, "orders", "billing", "c1", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		got := deliveries[0].Message()
+		require.Equal(t, id, got.ID)
+		require.Equal(t, "orders", got.Topic)
+		require.Equal(t, []byte("hello"), got.Payload)
+		require.Equal(t, "00-1-2-01", got.Headers["traceparent"])
+		require.Equal(t, 1, got.Attempts)
+		require.NoError(t, deliveries[0].Ack(ctx))
+
+		// 其他消费组也能收到
+		deliveries, err = d.Fetch(ctx, "orders", "shipping", "c1", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+
+		deliveries, err = d.Fetch(ctx, "orders", "billing", "c1", 10)
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	})
+
+	t.Run("nack and claim", func(t *testing.T) {
+		d := newDriver(t, WithClaimIdle(time.Second))
+		_, err := d.Publish(ctx, "jobs", mq.NewMessage([]byte("x")))
+		require.NoError(t, err)
+
+		deliveries, err := d.Fetch(ctx, "jobs", "g", "c1", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.NoError(t, deliveries[0].Nack(ctx, 0))
+
+		deliveries, err = d.Fetch(ctx, "jobs", "g", "c2", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, 2, deliveries[0].Message().Attempts)
+		require.NoError(t, deliveries[0].Ack(ctx))
+
+		deliveries, err = d.Fetch(ctx, "jobs", "g", "c1", 10)
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	})
+
+	t.Run("broker", func(t *testing.T) {
+		broker := mq.NewBroker(newDriver(t, WithClaimIdle(time.Second)))
+		var lock sync.Mutex
+		var attempts []int
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = broker.Subscribe(ctx, "flaky", "g", func(ctx context.Context, msg *mq.Message) error {
+				lock.Lock()
+				defer lock.Unlock()
+				attempts = append(attempts, msg.Attempts)
+				if msg.Attempts < 2 {
+					return errors.New("try again")
+				}
+				return nil
+			}, mq.WithRetryDelay(func(int) time.Duration { return 0 }))
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		require.NoError(t, broker.Publish(context.Background(), "flaky", mq.NewMessage([]byte("x"))))
+		require.Eventually(t, func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(attempts) == 2
+		}, 2*time.Second, 10*time.Millisecond)
+		require.Equal(t, []int{1, 2}, attempts)
+	})
+}
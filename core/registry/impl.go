@@ -52,6 +52,11 @@ func NewEtcdDriver(c *clientv3.Client) *EtcdDriver {
 	return &EtcdDriver{c: c}
 }
 
+// Close 关闭 etcd 客户端, 由容器在 App 停止时调用
+func (e *EtcdDriver) Close() error {
+	return e.c.Close()
+}
+
 func (e *EtcdDriver) Register(ctx context.Context, service string, addr string) (err error) {
 	var resp *clientv3.LeaseGrantResponse
 	{
@@ -0,0 +1,54 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	metricSdk "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// NewMeterProvider 创建指标上报的 provider, 与链路追踪使用同一个 otlp 地址
+func NewMeterProvider(conf *TraceConfig, interval time.Duration) (mp *metricSdk.MeterProvider, err error) {
+	var exporter metricSdk.Exporter
+
+	switch conf.Type {
+	case TraceTypeJaeger:
+		exporter, err = otlpmetricgrpc.New(context.Background(), otlpmetricgrpc.WithEndpointURL(conf.Endpoint))
+	default:
+		err = fmt.Errorf("unsupported metric type %s", conf.Type)
+	}
+	if err != nil {
+		return
+	}
+
+	r, err := newResource(conf)
+	if err != nil {
+		return
+	}
+
+	mp = metricSdk.NewMeterProvider(
+		metricSdk.WithReader(metricSdk.NewPeriodicReader(exporter, metricSdk.WithInterval(interval))),
+		metricSdk.WithResource(r),
+	)
+	return
+}
+
+// InitMeterProvider 创建指标上报的 provider 并设置为全局的 MeterProvider, 通过 otel.Meter 创建的指标都会上报
+func InitMeterProvider(conf *TraceConfig, interval time.Duration) (deferFunc func(), err error) {
+	deferFunc = func() {}
+	mp, err := NewMeterProvider(conf, interval)
+	if err != nil {
+		err = fmt.Errorf("create meter provider failed: %w", err)
+		return
+	}
+	otel.SetMeterProvider(mp)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		mp.Shutdown(ctx)
+	}, nil
+}
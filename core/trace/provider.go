@@ -19,6 +19,7 @@ type TraceConfig struct {
 	Endpoint string
 	Name     string
 	Addr     string
+	Metrics  bool // 是否同时通过 otlp 上报指标
 }
 
 func NewTraceProvider(conf *TraceConfig) (tp *traceSdk.TracerProvider, err error) {
@@ -34,6 +35,17 @@ func NewTraceProvider(conf *TraceConfig) (tp *traceSdk.TracerProvider, err error
 		return
 	}
 
+	r, err := newResource(conf)
+	if err != nil {
+		return
+	}
+
+	tp = traceSdk.NewTracerProvider(traceSdk.WithBatcher(exporter), traceSdk.WithResource(r))
+	return
+}
+
+// newResource 由服务名和地址构建上报的资源信息
+func newResource(conf *TraceConfig) (r *resource.Resource, err error) {
 	addr := strings.Split(conf.Addr, ":")
 	ip := addr[0]
 	port, err := strconv.Atoi(addr[1])
@@ -48,16 +60,10 @@ func NewTraceProvider(conf *TraceConfig) (tp *traceSdk.TracerProvider, err error
 		semconv.ServerAddress(ip),
 		semconv.ServerPort(port),
 	)
-	r, err := resource.Merge(resource.Default(), res)
-	if err != nil {
-		return
-	}
-
-	tp = traceSdk.NewTracerProvider(traceSdk.WithBatcher(exporter), traceSdk.WithResource(r))
-	return
+	return resource.Merge(resource.Default(), res)
 }
 
-func BuildTraceProviders(typ TraceType, endpoint string, serviceNames []string, addr string) (providers map[string]*traceSdk.TracerProvider, deferFunc func()) {
+func BuildTraceProviders(typ TraceType, endpoint string, serviceNames []string, addr string) (providers map[string]*traceSdk.TracerProvider, deferFunc func(), err error) {
 	deferFunc = func() {}
 	if typ == "" || endpoint == "" || len(serviceNames) == 0 || addr == "" {
 		return
@@ -68,7 +74,6 @@ func BuildTraceProviders(typ TraceType, endpoint string, serviceNames []string,
 		if strings.Contains(name, "grpc") {
 			continue
 		}
-		var err error
 		conf := &TraceConfig{
 			Type:     typ,
 			Endpoint: endpoint,
@@ -77,7 +82,8 @@ func BuildTraceProviders(typ TraceType, endpoint string, serviceNames []string,
 		}
 		providers[name], err = NewTraceProvider(conf)
 		if err != nil {
-			panic(fmt.Errorf("create trace provider failed: %w", err))
+			err = fmt.Errorf("create trace provider failed: %w", err)
+			return
 		}
 	}
 
@@ -87,7 +93,7 @@ func BuildTraceProviders(typ TraceType, endpoint string, serviceNames []string,
 		for _, tp := range providers {
 			tp.Shutdown(ctx)
 		}
-	}
+	}, nil
 }
 
 var providers map[string]*traceSdk.TracerProvider
@@ -97,6 +103,6 @@ func InitTraceProviders(typ TraceType, endpoint string, serviceNames []string, a
 		err = fmt.Errorf("trace providers already initialized")
 		return
 	}
-	providers, deferFunc = BuildTraceProviders(typ, endpoint, serviceNames, addr)
+	providers, deferFunc, err = BuildTraceProviders(typ, endpoint, serviceNames, addr)
 	return
 }
@@ -14,3 +14,22 @@ type TraceType string
 const (
 	TraceTypeJaeger TraceType = "jaeger"
 )
+
+// HeaderTextMapCarrier 使用消息头等 map[string]string 传递链路追踪上下文
+type HeaderTextMapCarrier map[string]string
+
+func (h HeaderTextMapCarrier) Get(key string) string {
+	return h[key]
+}
+
+func (h HeaderTextMapCarrier) Set(key string, value string) {
+	h[key] = value
+}
+
+func (h HeaderTextMapCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}